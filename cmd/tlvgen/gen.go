@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cloudcopper/core/tlv"
+)
+
+// The kind is the kind of Go value of field
+type kind int
+
+const (
+	kindUint kind = iota
+	kindInt
+	kindBool
+	kindString
+	kindBytes
+	kindStruct
+)
+
+// The field is single field of generated struct
+type field struct {
+	Name     string
+	T        int
+	Path     []int // TLV path
+	Kind     kind
+	Type     string // Go type of single value
+	Size     int    // size of integer value in octets
+	Pointer  bool
+	Repeated bool
+}
+
+// The structType is generated struct of TLV container
+type structType struct {
+	Name   string
+	Path   []int
+	Fields []field
+}
+
+// The namedType is generated integer type of enumerated value or flags
+type namedType struct {
+	Name  string
+	Path  []int
+	Base  string
+	Enum  tlv.Enum
+	Flags tlv.FlagSet
+}
+
+type declared struct {
+	path []int
+	def  *tlv.Definition
+	also []string // the other TLV paths of the same type
+}
+
+type generator struct {
+	structs []*structType
+	named   []*namedType
+	names   map[string]*declared // by Go type name
+	shared  [][]int              // the first paths of types declared for many TLV paths
+	imports map[string]bool
+}
+
+// The generate returns formatted Go source out of dictionary
+func generate(dict tlv.Dictionary, pkg, root string) ([]byte, error) {
+	g := &generator{
+		names:   map[string]*declared{},
+		imports: map[string]bool{"github.com/cloudcopper/core/encoding/tlv": true},
+	}
+	if err := g.container(root, nil, &tlv.Definition{Sub: dict}); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	g.print(&buf, pkg)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v", err)
+	}
+	return src, nil
+}
+
+// The declare reserves Go type name for TLV definition at path.
+// It returns false, if the type is already declared for the same definition
+// (i.e. YAML alias of container used in many places).
+func (g *generator) declare(name string, path []int, def *tlv.Definition) (bool, error) {
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return false, fmt.Errorf("%s: %q is not exported Go identifier", dotted(path), name)
+	}
+	if d, ok := g.names[name]; ok {
+		if def != nil && reflect.DeepEqual(d.def, def) {
+			if len(d.also) == 0 {
+				g.shared = append(g.shared, d.path)
+			}
+			d.also = append(d.also, dotted(path))
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: Go type %s already used by %s", dotted(path), name, dotted(d.path))
+	}
+	g.names[name] = &declared{path: path, def: def}
+	return true, nil
+}
+
+// The tag returns dotted TLV path for the generated code.
+// The path within type declared for many TLV paths starts at that type,
+// as the same Go type is used for all of them.
+func (g *generator) tag(path []int) string {
+	from := 0
+	for _, s := range g.shared {
+		if len(s) < len(path) && (from == 0 || len(s)-1 < from) && slices.Equal(s, path[:len(s)]) {
+			from = len(s) - 1
+		}
+	}
+	return dotted(path[from:])
+}
+
+// The paths returns dotted TLV paths of declared type name for its doc comment
+func (g *generator) paths(name string, path []int) string {
+	d := g.names[name]
+	if len(d.also) == 0 {
+		return g.tag(path)
+	}
+	return strings.Join(append([]string{g.tag(path)}, d.also...), ", ")
+}
+
+// The container collects struct type of TLV container and all types it needs
+func (g *generator) container(name string, path []int, def *tlv.Definition) error {
+	ok, err := g.declare(name, path, def)
+	if !ok || err != nil {
+		return err
+	}
+
+	st := &structType{Name: name, Path: path}
+	g.structs = append(g.structs, st)
+	for _, t := range types(def.Sub) {
+		f, err := g.field(def.Sub[t], append(path[:len(path):len(path)], t))
+		if err != nil {
+			return err
+		}
+		st.Fields = append(st.Fields, f)
+	}
+	return nil
+}
+
+// The field returns struct field of TLV definition
+func (g *generator) field(def *tlv.Definition, path []int) (field, error) {
+	name := exported(def.Name)
+	if !token.IsIdentifier(name) {
+		return field{}, fmt.Errorf("%s: %q is not Go identifier", dotted(path), def.Name)
+	}
+
+	f := field{Name: name, T: def.Type, Path: path, Repeated: def.Repeated}
+	switch {
+	case def.Sub != nil:
+		f.Kind = kindStruct
+		f.Type = name
+		if err := g.container(name, path, def); err != nil {
+			return field{}, err
+		}
+
+	case def.Enum != nil || def.Flags != nil:
+		base, ok := uintTypes[def.Size]
+		if !ok {
+			return field{}, fmt.Errorf("%s: enum or flags must have size 1, 2, 4 or 8", dotted(path))
+		}
+		for _, n := range append(def.Enum.Names(), def.Flags.Names()...) {
+			if !token.IsIdentifier(name + exported(n)) {
+				return field{}, fmt.Errorf("%s: %q is not Go identifier", dotted(path), n)
+			}
+		}
+		ok, err := g.declare(name, path, def)
+		if err != nil {
+			return field{}, err
+		}
+		if ok {
+			g.named = append(g.named, &namedType{name, path, base, def.Enum, def.Flags})
+		}
+		f.Kind = kindUint
+		f.Type = name
+		f.Size = def.Size
+
+	default:
+		f.Type = def.GoType
+		if f.Type == "" {
+			f.Type = "[]byte"
+			if t, ok := uintTypes[def.Size]; ok {
+				f.Type = t
+			}
+		}
+		switch f.Type {
+		case "uint8", "uint16", "uint32", "uint64":
+			f.Kind = kindUint
+			f.Size, _ = strconv.Atoi(f.Type[4:])
+			f.Size /= 8
+		case "int8", "int16", "int32", "int64":
+			f.Kind = kindInt
+			f.Size, _ = strconv.Atoi(f.Type[3:])
+			f.Size /= 8
+		case "bool":
+			f.Kind = kindBool
+		case "string":
+			f.Kind = kindString
+		case "[]byte":
+			f.Kind = kindBytes
+		case "net.IP", "net.HardwareAddr":
+			f.Kind = kindBytes
+			g.imports["net"] = true
+		default:
+			return field{}, fmt.Errorf("%s: unsupported Go type %q", dotted(path), f.Type)
+		}
+	}
+
+	// The nil slice tells absence, so no pointer is needed
+	f.Pointer = !def.Required && !def.Repeated && f.Kind != kindBytes
+	return f, nil
+}
+
+var uintTypes = map[int]string{1: "uint8", 2: "uint16", 4: "uint32", 8: "uint64"}
+
+func (g *generator) print(buf *bytes.Buffer, pkg string) {
+	fmt.Fprintf(buf, "// Code generated by tlvgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	imports := make([]string, 0, len(g.imports))
+	for i := range g.imports {
+		imports = append(imports, i)
+	}
+	// The standard packages go first
+	sort.Slice(imports, func(i, j int) bool {
+		si, sj := !strings.Contains(imports[i], "."), !strings.Contains(imports[j], ".")
+		if si != sj {
+			return si
+		}
+		return imports[i] < imports[j]
+	})
+	for i, imp := range imports {
+		if i > 0 && !strings.Contains(imports[i-1], ".") && strings.Contains(imp, ".") {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "%q\n", imp)
+	}
+	buf.WriteString(")\n")
+
+	for _, st := range g.structs {
+		g.printStruct(buf, st)
+		g.printUnmarshal(buf, st)
+		g.printMarshal(buf, st)
+	}
+	for _, nt := range g.named {
+		g.printNamed(buf, nt)
+	}
+}
+
+func (g *generator) printStruct(buf *bytes.Buffer, st *structType) {
+	if len(st.Path) == 0 {
+		fmt.Fprintf(buf, "\n// %s is the container of top-level TLVs\n", st.Name)
+	} else {
+		fmt.Fprintf(buf, "\n// %s is the TLV %s\n", st.Name, g.paths(st.Name, st.Path))
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", st.Name)
+	for _, f := range st.Fields {
+		t := f.Type
+		switch {
+		case f.Repeated:
+			t = "[]" + t
+		case f.Pointer:
+			t = "*" + t
+		}
+		fmt.Fprintf(buf, "%s %s `tlv:%q`\n", f.Name, t, g.tag(f.Path))
+	}
+	buf.WriteString("}\n")
+}
+
+func (g *generator) printUnmarshal(buf *bytes.Buffer, st *structType) {
+	fmt.Fprintf(buf, "\n// UnmarshalBinary decodes TLVs of %s without reflection\n", st.Name)
+	fmt.Fprintf(buf, "func (s *%s) UnmarshalBinary(data []byte) error {\n", st.Name)
+	buf.WriteString("return s.UnmarshalTLV(data, nil)\n}\n")
+
+	fmt.Fprintf(buf, "\n// UnmarshalTLV decodes TLVs of %s without reflection within budget b of limits\n", st.Name)
+	fmt.Fprintf(buf, "func (s *%s) UnmarshalTLV(data []byte, b *tlv.Budget) error {\n", st.Name)
+	fmt.Fprintf(buf, "*s = %s{}\n", st.Name)
+	if len(st.Fields) == 0 {
+		buf.WriteString("if len(data) > 0 {\nreturn tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, data[0], 0)\n}\nreturn nil\n}\n")
+		return
+	}
+
+	// The errors are located by the TLV type and its offset, as by reflection,
+	// and the errors of nested TLVs are located within this one
+	fail := "return tlv.WrapDecodeError(err, t, off)\n"
+	buf.WriteString("if err := b.Bytes(len(data)); err != nil {\nreturn &tlv.DecodeError{Offset: 0, Err: err}\n}\n")
+	buf.WriteString("for off := 0; len(data) > 0; {\n")
+	buf.WriteString("t, v, rest, err := tlv.T8L16(data).Read()\nif err != nil {\nreturn &tlv.DecodeError{Offset: off, Err: err}\n}\n")
+	buf.WriteString("if err := b.Enter(); err != nil {\n" + fail + "}\n")
+	buf.WriteString("switch t {\n")
+	for _, f := range st.Fields {
+		fmt.Fprintf(buf, "case %d:\n", f.T)
+		switch f.Kind {
+		case kindUint:
+			fmt.Fprintf(buf, "u, err := tlv.DecodeUint(v, %d)\nif err != nil {\n%s}\nx := %s(u)\n", f.Size, fail, f.Type)
+		case kindInt:
+			fmt.Fprintf(buf, "i, err := tlv.DecodeInt(v, %d)\nif err != nil {\n%s}\nx := %s(i)\n", f.Size, fail, f.Type)
+		case kindBool:
+			buf.WriteString("u, err := tlv.DecodeUint(v, 1)\nif err != nil {\n" + fail + "}\nx := u != 0\n")
+		case kindString:
+			buf.WriteString("if err := b.StringLen(len(v)); err != nil {\n" + fail + "}\n")
+			fmt.Fprintf(buf, "x := %s(v)\n", f.Type)
+		case kindBytes:
+			fmt.Fprintf(buf, "x := %s(append([]byte(nil), v...))\n", f.Type)
+		case kindStruct:
+			fmt.Fprintf(buf, "var x %s\nif err := x.UnmarshalTLV(v, b); err != nil {\n%s}\n", f.Type, fail)
+		}
+		switch {
+		case f.Repeated:
+			fmt.Fprintf(buf, "if err := b.SliceLen(len(s.%s) + 1); err != nil {\n%s}\n", f.Name, fail)
+			buf.WriteString("if err := b.Value(); err != nil {\n" + fail + "}\n")
+			fmt.Fprintf(buf, "s.%s = append(s.%s, x)\n", f.Name, f.Name)
+		case f.Pointer:
+			buf.WriteString("if err := b.Value(); err != nil {\n" + fail + "}\n")
+			fmt.Fprintf(buf, "s.%s = &x\n", f.Name)
+		default:
+			fmt.Fprintf(buf, "s.%s = x\n", f.Name)
+		}
+	}
+	buf.WriteString("default:\nreturn tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, t, off)\n}\n")
+	buf.WriteString("b.Leave()\noff += len(data) - len(rest)\ndata = rest\n}\nreturn nil\n}\n")
+}
+
+func (g *generator) printMarshal(buf *bytes.Buffer, st *structType) {
+	fmt.Fprintf(buf, "\n// MarshalBinary encodes TLVs of %s without reflection\n", st.Name)
+	fmt.Fprintf(buf, "func (s *%s) MarshalBinary() ([]byte, error) {\n", st.Name)
+	if len(st.Fields) == 0 {
+		buf.WriteString("return []byte{}, nil\n}\n")
+		return
+	}
+
+	buf.WriteString("b := []byte{}\nvar err error\n")
+	for _, f := range st.Fields {
+		switch {
+		case f.Repeated:
+			fmt.Fprintf(buf, "for _, x := range s.%s {\n", f.Name)
+		case f.Pointer:
+			fmt.Fprintf(buf, "if s.%s != nil {\nx := *s.%s\n", f.Name, f.Name)
+		case f.Kind == kindBytes:
+			fmt.Fprintf(buf, "if s.%s != nil {\nx := s.%s\n", f.Name, f.Name)
+		default:
+			fmt.Fprintf(buf, "{\nx := s.%s\n", f.Name)
+		}
+		switch f.Kind {
+		case kindUint, kindInt:
+			fmt.Fprintf(buf, "v := tlv.AppendUint(nil, uint64(x), %d)\n", f.Size)
+		case kindBool:
+			buf.WriteString("v := tlv.AppendBool(nil, x)\n")
+		case kindString, kindBytes:
+			buf.WriteString("v := []byte(x)\n")
+		case kindStruct:
+			buf.WriteString("v, e := x.MarshalBinary()\nif e != nil {\nreturn nil, e\n}\n")
+		}
+		fmt.Fprintf(buf, "if b, err = tlv.AppendT8L16(b, %d, v); err != nil {\nreturn nil, err\n}\n}\n", f.T)
+	}
+	buf.WriteString("return b, nil\n}\n")
+}
+
+func (g *generator) printNamed(buf *bytes.Buffer, nt *namedType) {
+	if nt.Enum != nil {
+		fmt.Fprintf(buf, "\n// %s is the enumerated value of TLV %s\n", nt.Name, g.paths(nt.Name, nt.Path))
+	} else {
+		fmt.Fprintf(buf, "\n// %s is the flags of TLV %s\n", nt.Name, g.paths(nt.Name, nt.Path))
+	}
+	fmt.Fprintf(buf, "type %s %s\n", nt.Name, nt.Base)
+
+	if nt.Flags != nil {
+		fmt.Fprintf(buf, "\n// The flags of %s\nconst (\n", nt.Name)
+		for _, bit := range nt.Flags.Bits() {
+			fmt.Fprintf(buf, "%s%s %s = 1 << %d\n", nt.Name, exported(nt.Flags[bit]), nt.Name, bit)
+		}
+		buf.WriteString(")\n")
+		return
+	}
+
+	values := nt.Enum.Values()
+	fmt.Fprintf(buf, "\n// The values of %s\nconst (\n", nt.Name)
+	for _, u := range values {
+		fmt.Fprintf(buf, "%s%s %s = %d\n", nt.Name, exported(nt.Enum[u]), nt.Name, u)
+	}
+	buf.WriteString(")\n")
+
+	fmt.Fprintf(buf, "\n// TLVEnumValues implements tlv.TLVEnum\nfunc (%s) TLVEnumValues() map[uint64]string {\nreturn map[uint64]string{\n", nt.Name)
+	for _, u := range values {
+		fmt.Fprintf(buf, "%d: %q,\n", u, nt.Enum[u])
+	}
+	buf.WriteString("}\n}\n")
+	fmt.Fprintf(buf, "\n// String implements fmt.Stringer\nfunc (v %s) String() string {\nreturn tlv.EnumString(v)\n}\n", nt.Name)
+}
+
+// The types returns sorted TLV types of dictionary
+func types(dict tlv.Dictionary) []int {
+	a := make([]int, 0, len(dict))
+	for t := range dict {
+		a = append(a, t)
+	}
+	sort.Ints(a)
+	return a
+}
+
+// The dotted returns TLV path in form "50.19.1"
+func dotted(path []int) string {
+	a := make([]string, len(path))
+	for i, t := range path {
+		a[i] = strconv.Itoa(t)
+	}
+	return strings.Join(a, ".")
+}
+
+// The exported returns s with upper case first letter
+func exported(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
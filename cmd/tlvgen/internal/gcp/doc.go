@@ -0,0 +1,5 @@
+// Package gcp is the example of Go types generated by tlvgen
+// out of R-PHY GCP/RCP dictionary.
+package gcp
+
+//go:generate go run github.com/cloudcopper/core/cmd/tlvgen -in gcp.yaml -out gcp_tlv.go -package gcp
@@ -0,0 +1,158 @@
+package gcp
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+	generic "github.com/cloudcopper/core/tlv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerated(t *testing.T) {
+	assert := assert.New(t)
+
+	msg, err := generic.Decode(`IRA(1):
+    Sequence(9):
+        SequenceNumber(10): uint16(1)
+        Operation(11): [7]
+        CcapCoreIdentification(60):
+            - CoreId(2):        11:22:33:44:55:66
+            - CoreIpAddress(3): 2fd0:100::1234
+            - IsPrincipal(4):   [0]
+            - CoreName(5):      "go-ccap"
+            - VendorId(6):      uint16(4491)
+            - CoreMode(7):      [2]
+            - CoreFunction(10): [0,16]
+`)
+	assert.NoError(err)
+	data, err := generic.Marshal(msg)
+	assert.NoError(err)
+
+	var v Message
+	rest, err := tlv.Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	if assert.NotNil(v.IRA) && assert.Len(v.IRA.Sequence, 1) {
+		s := v.IRA.Sequence[0]
+		assert.Equal(uint16(1), s.SequenceNumber)
+		assert.Equal(OperationAllocateWrite, s.Operation)
+		assert.Equal("AllocateWrite", s.Operation.String())
+		if assert.Len(s.CcapCoreIdentification, 1) {
+			c := s.CcapCoreIdentification[0]
+			assert.Nil(c.Index)
+			assert.Equal(net.HardwareAddr{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}, c.CoreId)
+			assert.Equal(net.ParseIP("2fd0:100::1234"), c.CoreIpAddress)
+			assert.Equal(false, *c.IsPrincipal)
+			assert.Equal("go-ccap", *c.CoreName)
+			assert.Equal(uint16(4491), *c.VendorId)
+			assert.Equal(CoreModeBackup, *c.CoreMode)
+			assert.Equal(CoreFunctionVideo, *c.CoreFunction)
+		}
+	}
+	assert.Nil(v.REX)
+
+	out, err := v.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal([]byte(data), out)
+
+	assert.Error(v.UnmarshalBinary([]byte{1, 0, 3, 5, 0, 0}))
+	assert.Error(v.UnmarshalBinary([]byte{1, 0, 8, 9, 0, 5, 10, 0, 2, 0}))
+}
+
+func TestGeneratedErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	// The errors are located as by reflection based decoding
+	cases := []struct {
+		data   []byte
+		path   []int
+		offset int
+		err    error
+	}{
+		{[]byte{1, 0, 3, 5, 0, 0}, []int{1, 5}, 3, tlv.ErrTlvMapHasNoEntry},
+		{[]byte{1, 0, 7, 9, 0, 4, 10, 0, 2, 0}, []int{1, 9}, 6, nil},
+		{[]byte{1, 0, 0, 2, 0, 8, 9, 0, 5, 11, 0, 2, 1, 2}, []int{2, 9, 11}, 9, tlv.ErrValueTooLong},
+		{[]byte{1, 0, 8, 9, 0, 5, 11, 0, 2, 1, 2}, []int{1, 9, 11}, 6, tlv.ErrValueTooLong},
+	}
+	for _, c := range cases {
+		var v Message
+		err := v.UnmarshalBinary(c.data)
+		var de *tlv.DecodeError
+		if !assert.ErrorAs(err, &de, "%v", c.data) {
+			continue
+		}
+		assert.Equal(c.path, de.Path, "%v", c.data)
+		assert.Equal(c.offset, de.Offset, "%v", c.data)
+		if c.err != nil {
+			assert.ErrorIs(err, c.err, "%v", c.data)
+		}
+
+		_, err = tlv.Unmarshal(c.data, &Message{})
+		var rde *tlv.DecodeError
+		if assert.ErrorAs(err, &rde, "%v", c.data) {
+			assert.Equal(de.Path, rde.Path, "%v", c.data)
+			assert.Equal(de.Offset, rde.Offset, "%v", c.data)
+		}
+	}
+}
+
+func TestGeneratedLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	// The sequence of 3 cores with names
+	core := []byte{5, 0, 3, 'a', 'b', 'c'}
+	seq := []byte{}
+	for i := 0; i < 3; i++ {
+		seq, _ = tlv.AppendT8L16(seq, 60, core)
+	}
+	seq, _ = tlv.AppendT8L16(nil, 9, seq)
+	data, _ := tlv.AppendT8L16(nil, 1, seq)
+
+	var v Message
+	assert.NoError(v.UnmarshalTLV(data, tlv.NewBudget(tlv.Limits{MaxDepth: 4, MaxSliceLen: 3, MaxStringLen: 3})))
+	assert.Len(v.IRA.Sequence[0].CcapCoreIdentification, 3)
+
+	err := v.UnmarshalTLV(data, tlv.NewBudget(tlv.Limits{MaxSliceLen: 2}))
+	assert.ErrorIs(err, tlv.ErrLimitExceeded)
+	assert.EqualError(err, "1/9/60 at offset 24: limit exceeded: max slice length 2")
+	err = v.UnmarshalTLV(data, tlv.NewBudget(tlv.Limits{MaxStringLen: 2}))
+	assert.EqualError(err, "1/9/60/5 at offset 9: limit exceeded: max string length 2")
+
+	// The reflection based decoding gives its budget to generated one
+	_, err = tlv.UnmarshalWithOptions(data, &v, tlv.DecodeOptions{Limits: tlv.Limits{MaxDepth: 3}})
+	assert.ErrorIs(err, tlv.ErrLimitExceeded)
+	var le *tlv.LimitError
+	if assert.ErrorAs(err, &le) {
+		assert.Equal("depth", le.Limit)
+	}
+}
+
+// The generated decoders never panic, and decode own encoding to the same value
+func FuzzGenerated(f *testing.F) {
+	f.Add([]byte{1, 0, 8, 9, 0, 5, 10, 0, 2, 0, 1})
+	f.Add([]byte{2, 0, 17, 9, 0, 14, 60, 0, 11, 2, 0, 2, 0x11, 0x22, 3, 0, 1, 10, 7, 0, 0})
+	f.Add([]byte{1, 0, 3, 5, 0, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v Message
+		if err := v.UnmarshalBinary(data); err != nil {
+			return
+		}
+		out, err := v.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v2 Message
+		if err := v2.UnmarshalBinary(out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, v2) {
+			t.Fatalf("decoded %+v, and then %+v", v, v2)
+		}
+
+		// The reflection based decoding does not panic either
+		var v3 Message
+		_, _ = tlv.Unmarshal(data, &v3)
+	})
+}
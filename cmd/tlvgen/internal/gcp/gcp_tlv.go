@@ -0,0 +1,565 @@
+// Code generated by tlvgen. DO NOT EDIT.
+
+package gcp
+
+import (
+	"net"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+)
+
+// Message is the container of top-level TLVs
+type Message struct {
+	IRA *IRA `tlv:"1"`
+	REX *REX `tlv:"2"`
+}
+
+// UnmarshalBinary decodes TLVs of Message without reflection
+func (s *Message) UnmarshalBinary(data []byte) error {
+	return s.UnmarshalTLV(data, nil)
+}
+
+// UnmarshalTLV decodes TLVs of Message without reflection within budget b of limits
+func (s *Message) UnmarshalTLV(data []byte, b *tlv.Budget) error {
+	*s = Message{}
+	if err := b.Bytes(len(data)); err != nil {
+		return &tlv.DecodeError{Offset: 0, Err: err}
+	}
+	for off := 0; len(data) > 0; {
+		t, v, rest, err := tlv.T8L16(data).Read()
+		if err != nil {
+			return &tlv.DecodeError{Offset: off, Err: err}
+		}
+		if err := b.Enter(); err != nil {
+			return tlv.WrapDecodeError(err, t, off)
+		}
+		switch t {
+		case 1:
+			var x IRA
+			if err := x.UnmarshalTLV(v, b); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.IRA = &x
+		case 2:
+			var x REX
+			if err := x.UnmarshalTLV(v, b); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.REX = &x
+		default:
+			return tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, t, off)
+		}
+		b.Leave()
+		off += len(data) - len(rest)
+		data = rest
+	}
+	return nil
+}
+
+// MarshalBinary encodes TLVs of Message without reflection
+func (s *Message) MarshalBinary() ([]byte, error) {
+	b := []byte{}
+	var err error
+	if s.IRA != nil {
+		x := *s.IRA
+		v, e := x.MarshalBinary()
+		if e != nil {
+			return nil, e
+		}
+		if b, err = tlv.AppendT8L16(b, 1, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.REX != nil {
+		x := *s.REX
+		v, e := x.MarshalBinary()
+		if e != nil {
+			return nil, e
+		}
+		if b, err = tlv.AppendT8L16(b, 2, v); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// IRA is the TLV 1
+type IRA struct {
+	Sequence []Sequence `tlv:"1.9"`
+}
+
+// UnmarshalBinary decodes TLVs of IRA without reflection
+func (s *IRA) UnmarshalBinary(data []byte) error {
+	return s.UnmarshalTLV(data, nil)
+}
+
+// UnmarshalTLV decodes TLVs of IRA without reflection within budget b of limits
+func (s *IRA) UnmarshalTLV(data []byte, b *tlv.Budget) error {
+	*s = IRA{}
+	if err := b.Bytes(len(data)); err != nil {
+		return &tlv.DecodeError{Offset: 0, Err: err}
+	}
+	for off := 0; len(data) > 0; {
+		t, v, rest, err := tlv.T8L16(data).Read()
+		if err != nil {
+			return &tlv.DecodeError{Offset: off, Err: err}
+		}
+		if err := b.Enter(); err != nil {
+			return tlv.WrapDecodeError(err, t, off)
+		}
+		switch t {
+		case 9:
+			var x Sequence
+			if err := x.UnmarshalTLV(v, b); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.SliceLen(len(s.Sequence) + 1); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.Sequence = append(s.Sequence, x)
+		default:
+			return tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, t, off)
+		}
+		b.Leave()
+		off += len(data) - len(rest)
+		data = rest
+	}
+	return nil
+}
+
+// MarshalBinary encodes TLVs of IRA without reflection
+func (s *IRA) MarshalBinary() ([]byte, error) {
+	b := []byte{}
+	var err error
+	for _, x := range s.Sequence {
+		v, e := x.MarshalBinary()
+		if e != nil {
+			return nil, e
+		}
+		if b, err = tlv.AppendT8L16(b, 9, v); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Sequence is the TLV 1.9, 2.9
+type Sequence struct {
+	SequenceNumber         uint16                   `tlv:"9.10"`
+	Operation              Operation                `tlv:"9.11"`
+	CcapCoreIdentification []CcapCoreIdentification `tlv:"9.60"`
+}
+
+// UnmarshalBinary decodes TLVs of Sequence without reflection
+func (s *Sequence) UnmarshalBinary(data []byte) error {
+	return s.UnmarshalTLV(data, nil)
+}
+
+// UnmarshalTLV decodes TLVs of Sequence without reflection within budget b of limits
+func (s *Sequence) UnmarshalTLV(data []byte, b *tlv.Budget) error {
+	*s = Sequence{}
+	if err := b.Bytes(len(data)); err != nil {
+		return &tlv.DecodeError{Offset: 0, Err: err}
+	}
+	for off := 0; len(data) > 0; {
+		t, v, rest, err := tlv.T8L16(data).Read()
+		if err != nil {
+			return &tlv.DecodeError{Offset: off, Err: err}
+		}
+		if err := b.Enter(); err != nil {
+			return tlv.WrapDecodeError(err, t, off)
+		}
+		switch t {
+		case 10:
+			u, err := tlv.DecodeUint(v, 2)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := uint16(u)
+			s.SequenceNumber = x
+		case 11:
+			u, err := tlv.DecodeUint(v, 1)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := Operation(u)
+			s.Operation = x
+		case 60:
+			var x CcapCoreIdentification
+			if err := x.UnmarshalTLV(v, b); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.SliceLen(len(s.CcapCoreIdentification) + 1); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.CcapCoreIdentification = append(s.CcapCoreIdentification, x)
+		default:
+			return tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, t, off)
+		}
+		b.Leave()
+		off += len(data) - len(rest)
+		data = rest
+	}
+	return nil
+}
+
+// MarshalBinary encodes TLVs of Sequence without reflection
+func (s *Sequence) MarshalBinary() ([]byte, error) {
+	b := []byte{}
+	var err error
+	{
+		x := s.SequenceNumber
+		v := tlv.AppendUint(nil, uint64(x), 2)
+		if b, err = tlv.AppendT8L16(b, 10, v); err != nil {
+			return nil, err
+		}
+	}
+	{
+		x := s.Operation
+		v := tlv.AppendUint(nil, uint64(x), 1)
+		if b, err = tlv.AppendT8L16(b, 11, v); err != nil {
+			return nil, err
+		}
+	}
+	for _, x := range s.CcapCoreIdentification {
+		v, e := x.MarshalBinary()
+		if e != nil {
+			return nil, e
+		}
+		if b, err = tlv.AppendT8L16(b, 60, v); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// CcapCoreIdentification is the TLV 9.60
+type CcapCoreIdentification struct {
+	Index                        *uint8           `tlv:"9.60.1"`
+	CoreId                       net.HardwareAddr `tlv:"9.60.2"`
+	CoreIpAddress                net.IP           `tlv:"9.60.3"`
+	IsPrincipal                  *bool            `tlv:"9.60.4"`
+	CoreName                     *string          `tlv:"9.60.5"`
+	VendorId                     *uint16          `tlv:"9.60.6"`
+	CoreMode                     *CoreMode        `tlv:"9.60.7"`
+	InitialConfigurationComplete *bool            `tlv:"9.60.8"`
+	CoreFunction                 *CoreFunction    `tlv:"9.60.10"`
+}
+
+// UnmarshalBinary decodes TLVs of CcapCoreIdentification without reflection
+func (s *CcapCoreIdentification) UnmarshalBinary(data []byte) error {
+	return s.UnmarshalTLV(data, nil)
+}
+
+// UnmarshalTLV decodes TLVs of CcapCoreIdentification without reflection within budget b of limits
+func (s *CcapCoreIdentification) UnmarshalTLV(data []byte, b *tlv.Budget) error {
+	*s = CcapCoreIdentification{}
+	if err := b.Bytes(len(data)); err != nil {
+		return &tlv.DecodeError{Offset: 0, Err: err}
+	}
+	for off := 0; len(data) > 0; {
+		t, v, rest, err := tlv.T8L16(data).Read()
+		if err != nil {
+			return &tlv.DecodeError{Offset: off, Err: err}
+		}
+		if err := b.Enter(); err != nil {
+			return tlv.WrapDecodeError(err, t, off)
+		}
+		switch t {
+		case 1:
+			u, err := tlv.DecodeUint(v, 1)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := uint8(u)
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.Index = &x
+		case 2:
+			x := net.HardwareAddr(append([]byte(nil), v...))
+			s.CoreId = x
+		case 3:
+			x := net.IP(append([]byte(nil), v...))
+			s.CoreIpAddress = x
+		case 4:
+			u, err := tlv.DecodeUint(v, 1)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := u != 0
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.IsPrincipal = &x
+		case 5:
+			if err := b.StringLen(len(v)); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := string(v)
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.CoreName = &x
+		case 6:
+			u, err := tlv.DecodeUint(v, 2)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := uint16(u)
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.VendorId = &x
+		case 7:
+			u, err := tlv.DecodeUint(v, 1)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := CoreMode(u)
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.CoreMode = &x
+		case 8:
+			u, err := tlv.DecodeUint(v, 1)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := u != 0
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.InitialConfigurationComplete = &x
+		case 10:
+			u, err := tlv.DecodeUint(v, 2)
+			if err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			x := CoreFunction(u)
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.CoreFunction = &x
+		default:
+			return tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, t, off)
+		}
+		b.Leave()
+		off += len(data) - len(rest)
+		data = rest
+	}
+	return nil
+}
+
+// MarshalBinary encodes TLVs of CcapCoreIdentification without reflection
+func (s *CcapCoreIdentification) MarshalBinary() ([]byte, error) {
+	b := []byte{}
+	var err error
+	if s.Index != nil {
+		x := *s.Index
+		v := tlv.AppendUint(nil, uint64(x), 1)
+		if b, err = tlv.AppendT8L16(b, 1, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.CoreId != nil {
+		x := s.CoreId
+		v := []byte(x)
+		if b, err = tlv.AppendT8L16(b, 2, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.CoreIpAddress != nil {
+		x := s.CoreIpAddress
+		v := []byte(x)
+		if b, err = tlv.AppendT8L16(b, 3, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.IsPrincipal != nil {
+		x := *s.IsPrincipal
+		v := tlv.AppendBool(nil, x)
+		if b, err = tlv.AppendT8L16(b, 4, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.CoreName != nil {
+		x := *s.CoreName
+		v := []byte(x)
+		if b, err = tlv.AppendT8L16(b, 5, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.VendorId != nil {
+		x := *s.VendorId
+		v := tlv.AppendUint(nil, uint64(x), 2)
+		if b, err = tlv.AppendT8L16(b, 6, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.CoreMode != nil {
+		x := *s.CoreMode
+		v := tlv.AppendUint(nil, uint64(x), 1)
+		if b, err = tlv.AppendT8L16(b, 7, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.InitialConfigurationComplete != nil {
+		x := *s.InitialConfigurationComplete
+		v := tlv.AppendBool(nil, x)
+		if b, err = tlv.AppendT8L16(b, 8, v); err != nil {
+			return nil, err
+		}
+	}
+	if s.CoreFunction != nil {
+		x := *s.CoreFunction
+		v := tlv.AppendUint(nil, uint64(x), 2)
+		if b, err = tlv.AppendT8L16(b, 10, v); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// REX is the TLV 2
+type REX struct {
+	Sequence []Sequence `tlv:"2.9"`
+}
+
+// UnmarshalBinary decodes TLVs of REX without reflection
+func (s *REX) UnmarshalBinary(data []byte) error {
+	return s.UnmarshalTLV(data, nil)
+}
+
+// UnmarshalTLV decodes TLVs of REX without reflection within budget b of limits
+func (s *REX) UnmarshalTLV(data []byte, b *tlv.Budget) error {
+	*s = REX{}
+	if err := b.Bytes(len(data)); err != nil {
+		return &tlv.DecodeError{Offset: 0, Err: err}
+	}
+	for off := 0; len(data) > 0; {
+		t, v, rest, err := tlv.T8L16(data).Read()
+		if err != nil {
+			return &tlv.DecodeError{Offset: off, Err: err}
+		}
+		if err := b.Enter(); err != nil {
+			return tlv.WrapDecodeError(err, t, off)
+		}
+		switch t {
+		case 9:
+			var x Sequence
+			if err := x.UnmarshalTLV(v, b); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.SliceLen(len(s.Sequence) + 1); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			if err := b.Value(); err != nil {
+				return tlv.WrapDecodeError(err, t, off)
+			}
+			s.Sequence = append(s.Sequence, x)
+		default:
+			return tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, t, off)
+		}
+		b.Leave()
+		off += len(data) - len(rest)
+		data = rest
+	}
+	return nil
+}
+
+// MarshalBinary encodes TLVs of REX without reflection
+func (s *REX) MarshalBinary() ([]byte, error) {
+	b := []byte{}
+	var err error
+	for _, x := range s.Sequence {
+		v, e := x.MarshalBinary()
+		if e != nil {
+			return nil, e
+		}
+		if b, err = tlv.AppendT8L16(b, 9, v); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Operation is the enumerated value of TLV 9.11
+type Operation uint8
+
+// The values of Operation
+const (
+	OperationRead                  Operation = 1
+	OperationWrite                 Operation = 2
+	OperationDelete                Operation = 3
+	OperationReadResponse          Operation = 4
+	OperationWriteResponse         Operation = 5
+	OperationDeleteResponse        Operation = 6
+	OperationAllocateWrite         Operation = 7
+	OperationAllocateWriteResponse Operation = 8
+)
+
+// TLVEnumValues implements tlv.TLVEnum
+func (Operation) TLVEnumValues() map[uint64]string {
+	return map[uint64]string{
+		1: "Read",
+		2: "Write",
+		3: "Delete",
+		4: "ReadResponse",
+		5: "WriteResponse",
+		6: "DeleteResponse",
+		7: "AllocateWrite",
+		8: "AllocateWriteResponse",
+	}
+}
+
+// String implements fmt.Stringer
+func (v Operation) String() string {
+	return tlv.EnumString(v)
+}
+
+// CoreMode is the enumerated value of TLV 9.60.7
+type CoreMode uint8
+
+// The values of CoreMode
+const (
+	CoreModeActive    CoreMode = 1
+	CoreModeBackup    CoreMode = 2
+	CoreModeNotActing CoreMode = 3
+)
+
+// TLVEnumValues implements tlv.TLVEnum
+func (CoreMode) TLVEnumValues() map[uint64]string {
+	return map[uint64]string{
+		1: "Active",
+		2: "Backup",
+		3: "NotActing",
+	}
+}
+
+// String implements fmt.Stringer
+func (v CoreMode) String() string {
+	return tlv.EnumString(v)
+}
+
+// CoreFunction is the flags of TLV 9.60.10
+type CoreFunction uint16
+
+// The flags of CoreFunction
+const (
+	CoreFunctionPrincipal CoreFunction = 1 << 0
+	CoreFunctionDocsisMac CoreFunction = 1 << 1
+	CoreFunctionVideo     CoreFunction = 1 << 4
+)
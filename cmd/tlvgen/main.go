@@ -0,0 +1,67 @@
+// Command tlvgen generates Go types out of YAML TLV dictionary.
+//
+// The dictionary format is described by tlv.ParseDictionary.
+// Each TLV with sub-elements becomes a struct type with `tlv` tagged fields,
+// and each TLV with enum or flags becomes a named integer type with constants.
+// The optional TLVs are pointers, and the repeated TLVs are slices.
+// The struct types implement encoding.BinaryUnmarshaler and encoding.BinaryMarshaler
+// without reflection, so encoding/tlv.Unmarshal uses those as well.
+// The UnmarshalTLV decodes within encoding/tlv.Budget of limits for untrusted data,
+// and it is given the budget of encoding/tlv.UnmarshalWithOptions.
+// The errors are encoding/tlv.DecodeError with the path and offset of failed TLV,
+// as of the reflection based decoding.
+//
+// The top-level TLVs of dictionary are fields of the root struct type.
+// The container used for many TLV paths (i.e. YAML alias) is single Go type,
+// and the tags of its fields are relative to it, i.e. `tlv:"9.10"`.
+//
+// Usage:
+//
+//	//go:generate go run github.com/cloudcopper/core/cmd/tlvgen -in gcp.yaml -out gcp_tlv.go -package gcp
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cloudcopper/core/tlv"
+)
+
+func main() {
+	in := flag.String("in", "", "input YAML TLV dictionary")
+	out := flag.String("out", "", "output Go file, stdout if empty")
+	pkg := flag.String("package", "main", "Go package name")
+	root := flag.String("root", "Message", "Go type name of top-level TLVs")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg, *root); err != nil {
+		fmt.Fprintf(os.Stderr, "tlvgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg, root string) error {
+	if in == "" {
+		return fmt.Errorf("no input dictionary")
+	}
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	dict, err := tlv.ParseDictionary(data)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(dict, pkg, root)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0644)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cloudcopper/core/tlv"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerateExample checks the generated example is up to date
+func TestGenerateExample(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := os.ReadFile("internal/gcp/gcp.yaml")
+	assert.NoError(err)
+	dict, err := tlv.ParseDictionary(data)
+	assert.NoError(err)
+
+	src, err := generate(dict, "gcp", "Message")
+	assert.NoError(err)
+	exp, err := os.ReadFile("internal/gcp/gcp_tlv.go")
+	assert.NoError(err)
+	assert.Equal(string(exp), string(src), "run go generate ./...")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []string{
+		// not Go identifier
+		"- {name: Core-Id, type: 9, sub: []}",
+		// same name of different containers
+		"- {name: A, type: 1, sub: [{name: B, type: 1, sub: []}]}\n- {name: C, type: 2, sub: [{name: B, type: 2, sub: []}]}",
+		// enum without size
+		"- {name: Mode, type: 1, enum: {1: Active}}",
+		// bad enum name
+		"- {name: Mode, type: 1, size: 1, enum: {1: Not Active}}",
+		// unsupported Go type
+		"- {name: Mode, type: 1, goType: float32}",
+	}
+	for _, c := range cases {
+		dict, err := tlv.ParseDictionary([]byte(c))
+		if !assert.NoError(err) {
+			continue
+		}
+		_, err = generate(dict, "test", "Message")
+		assert.Error(err, c)
+	}
+}
+
+func TestGenerateEmptyStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	dict, err := tlv.ParseDictionary([]byte("- {name: Ping, type: 1, sub: []}"))
+	assert.NoError(err)
+	src, err := generate(dict, "test", "Message")
+	assert.NoError(err)
+
+	// The unknown TLV within empty struct is located by type, as in other structs
+	assert.Contains(string(src), "return tlv.WrapDecodeError(tlv.ErrTlvMapHasNoEntry, data[0], 0)")
+}
+
+func TestGenerateSharedType(t *testing.T) {
+	assert := assert.New(t)
+
+	dict, err := tlv.ParseDictionary([]byte(`
+- {name: A, type: 1, sub: &s [{name: S, type: 9, sub: [{name: X, type: 3, size: 1}]}]}
+- {name: B, type: 2, sub: *s}
+`))
+	assert.NoError(err)
+	src, err := generate(dict, "test", "Message")
+	assert.NoError(err)
+
+	// The tags within shared type are relative to it, as it decodes all its paths
+	assert.Contains(string(src), "// S is the TLV 1.9, 2.9\n")
+	assert.Contains(string(src), "X *uint8 `tlv:\"9.3\"`")
+	assert.Contains(string(src), "S *S `tlv:\"1.9\"`")
+	assert.Contains(string(src), "S *S `tlv:\"2.9\"`")
+}
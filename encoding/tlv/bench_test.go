@@ -0,0 +1,118 @@
+package tlv
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// The benchmarks use shapes of examples and of R-PHY GCP message
+
+type benchCore struct {
+	Index        *uint8 `tlv:"9.60.1"`
+	CoreID       []byte `tlv:"9.60.2"`
+	CoreIP       net.IP `tlv:"9.60.3"`
+	IsPrincipal  bool   `tlv:"9.60.4"`
+	CoreName     string `tlv:"9.60.5"`
+	VendorID     uint16 `tlv:"9.60.6"`
+	CoreMode     uint8  `tlv:"9.60.7"`
+	CoreFunction uint16 `tlv:"9.60.10"`
+}
+
+type benchSequence struct {
+	SequenceNumber uint16      `tlv:"9.10"`
+	Operation      uint8       `tlv:"9.11"`
+	Cores          []benchCore `tlv:"9.60"`
+}
+
+type benchMessage struct {
+	Sequence []benchSequence `tlv:"9"`
+}
+
+func benchTLV(b []byte, t byte, v []byte) []byte {
+	b, err := AppendT8L16(b, t, v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func benchMessageData(cores int) T8L16 {
+	var core []byte
+	core = benchTLV(core, 1, []byte{1})
+	core = benchTLV(core, 2, []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66})
+	core = benchTLV(core, 3, net.ParseIP("2fd0:100::1234"))
+	core = benchTLV(core, 4, []byte{0})
+	core = benchTLV(core, 5, []byte("go-ccap"))
+	core = benchTLV(core, 6, []byte{0x11, 0x8b})
+	core = benchTLV(core, 7, []byte{2})
+	core = benchTLV(core, 10, []byte{0, 16})
+
+	var seq []byte
+	seq = benchTLV(seq, 10, []byte{0, 1})
+	seq = benchTLV(seq, 11, []byte{7})
+	for i := 0; i < cores; i++ {
+		seq = benchTLV(seq, 60, core)
+	}
+	return benchTLV(nil, 9, seq)
+}
+
+func BenchmarkUnmarshalStruct(b *testing.B) {
+	type Struct struct {
+		A uint16  `tlv:"1.1"`
+		B string  `tlv:"1.2"`
+		C *string `tlv:"1.3"`
+		D *uint16 `tlv:"1.4"`
+	}
+	type Out struct {
+		Out1 Struct `tlv:"1"`
+	}
+	data := T8L16{1, 0, 17, 1, 0, 2, 0xDE, 0xAD, 2, 0, 4, 'a', 'b', 'c', 'd', 4, 0, 2, 0x11, 0x22}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v Out
+		if _, err := Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalMessage(b *testing.B) {
+	data := benchMessageData(16)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var v benchMessage
+		if _, err := Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalSliceOfEmptyInterface(b *testing.B) {
+	type Struct1 struct {
+		Value string `tlv:"1.1"`
+	}
+	type Struct2 struct {
+		Value uint32 `tlv:"2.1"`
+	}
+	m := Map{
+		1: {T: reflect.TypeOf(Struct1{})},
+		2: {T: reflect.TypeOf(Struct2{})},
+	}
+	var data T8L16
+	for i := 0; i < 16; i++ {
+		data = benchTLV(data, 1, benchTLV(nil, 1, []byte("this is Struct1")))
+		data = benchTLV(data, 2, benchTLV(nil, 1, []byte{1, 2, 3, 4}))
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v []interface{}
+		if _, err := Unmarshal(data, &v, m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tlv
+
+// This file has support of bit-fields mapping of TLV values.
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The bitField is single field of TLV value mapped by `bits` struct tag.
+// The bits are numbered from least significant bit 0,
+// and lo and hi are inclusive.
+// The bits are unsigned value for signed fields too, those are not sign extended.
+type bitField struct {
+	K      string
+	index  []int // index of field K, to avoid lookup by name
+	lo, hi uint
+}
+
+// The parseBitsTag parses `bits` struct tag "N" or "N-M" of the field sf.
+func parseBitsTag(sf reflect.StructField, tag string) (bitField, error) {
+	var lo, hi uint64
+	var err error
+	a := strings.SplitN(tag, "-", 2)
+	if lo, err = strconv.ParseUint(a[0], 10, 6); err != nil {
+		return bitField{}, ErrBadBitsTag
+	}
+	hi = lo
+	if len(a) == 2 {
+		if hi, err = strconv.ParseUint(a[1], 10, 6); err != nil {
+			return bitField{}, ErrBadBitsTag
+		}
+	}
+	if hi < lo {
+		return bitField{}, ErrBadBitsTag
+	}
+
+	width := int(hi - lo + 1)
+	switch k := sf.Type.Kind(); k {
+	case reflect.Bool:
+		if width != 1 {
+			return bitField{}, ErrBadBitsTag
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if width > 8*int(sf.Type.Size()) {
+			return bitField{}, ErrBadBitsTag
+		}
+	default:
+		return bitField{}, &WrongKindError{Kind: k}
+	}
+
+	return bitField{sf.Name, sf.Index, uint(lo), uint(hi)}, nil
+}
+
+// The getBitFields returns cached bit-fields of struct type t.
+// The struct type is a bit-fields struct, when it has `bits` but no `tlv` struct tags.
+// It returns nil for any other type.
+func getBitFields(t reflect.Type) ([]bitField, error) {
+	if bf, ok := cacheBitFields.Load(t); ok {
+		return bf.([]bitField), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var bf []bitField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if _, ok := sf.Tag.Lookup("tlv"); ok {
+			bf = nil
+			break
+		}
+		tag, ok := sf.Tag.Lookup("bits")
+		if !ok {
+			continue
+		}
+		f, err := parseBitsTag(sf, tag)
+		if err != nil {
+			return nil, err
+		}
+		bf = append(bf, f)
+	}
+
+	cacheBitFields.Store(t, bf)
+	return bf, nil
+}
+
+var cacheBitFields sync.Map // map[reflect.Type][]bitField
+
+// The unmarshalBits decodes data as unsigned integer in network byte order,
+// and stores its bits to fields of rv according to bf.
+func unmarshalBits(data []byte, rv reflect.Value, bf []bitField) error {
+	if len(data) > 8 {
+		return ErrValueTooLong
+	}
+
+	var u uint64
+	for _, b := range data {
+		u = u<<8 | uint64(b)
+	}
+
+	for _, f := range bf {
+		v := u >> f.lo
+		if w := f.hi - f.lo + 1; w < 64 {
+			v &= 1<<w - 1
+		}
+
+		fv := rv.FieldByIndex(f.index)
+		switch fv.Kind() {
+		case reflect.Bool:
+			fv.SetBool(v != 0)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// The bits are not sign extended (see bitField)
+			fv.SetInt(int64(v))
+		default:
+			fv.SetUint(v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package tlv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCoreFunction struct {
+	Principal bool  `bits:"0"`
+	Auxiliary bool  `bits:"4"`
+	Mode      uint8 `bits:"8-10"`
+	Level     int   `bits:"12-15"`
+}
+
+type TestStructBits struct {
+	Function  TestCoreFunction   `tlv:"10"`
+	Functions []TestCoreFunction `tlv:"11"`
+	Enable    bool               `tlv:"12" bits:"0"`
+	Priority  uint8              `tlv:"12" bits:"3-5"`
+}
+
+func TestUnmarshalBits(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{
+		10, 0, 2, 0xA5, 0x11,
+		11, 0, 1, 0x01,
+		11, 0, 2, 0x02, 0x10,
+		12, 0, 1, 0x29,
+	}
+	var v TestStructBits
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(TestCoreFunction{true, true, 5, 10}, v.Function)
+	assert.Equal([]TestCoreFunction{{Principal: true}, {Mode: 2, Auxiliary: true}}, v.Functions)
+	assert.True(v.Enable)
+	assert.Equal(uint8(5), v.Priority)
+
+	_, err = Unmarshal(T8L16{10, 0, 9, 1, 2, 3, 4, 5, 6, 7, 8, 9}, &v)
+	assert.ErrorIs(err, ErrValueTooLong)
+}
+
+func TestBitsTag(t *testing.T) {
+	assert := assert.New(t)
+
+	type Bad1 struct {
+		A bool `bits:"1-2"`
+	}
+	type Bad2 struct {
+		A uint8 `bits:"0-8"`
+	}
+	type Bad3 struct {
+		A uint8 `bits:"3-1"`
+	}
+	type Bad4 struct {
+		A string `bits:"1"`
+	}
+	type Bad5 struct {
+		A uint8 `bits:"x"`
+	}
+	for _, v := range []interface{}{Bad1{}, Bad2{}, Bad3{}, Bad5{}} {
+		_, err := getBitFields(reflect.TypeOf(v))
+		assert.Equal(ErrBadBitsTag, err, "%T", v)
+	}
+	_, err := getBitFields(reflect.TypeOf(Bad4{}))
+	assert.IsType(&WrongKindError{}, err)
+
+	type Mixed struct {
+		A uint8 `tlv:"1"`
+		B bool  `tlv:"1" bits:"0"`
+	}
+	_, err = getTlvMap(reflect.TypeOf(Mixed{}))
+	assert.Equal(ErrBadBitsTag, err)
+
+	// The later field does not replace bit-fields either
+	type Override struct {
+		B bool  `tlv:"1" bits:"0"`
+		A uint8 `tlv:"1"`
+	}
+	_, err = getTlvMap(reflect.TypeOf(Override{}))
+	assert.Equal(ErrBadBitsTag, err)
+}
+
+func TestUnmarshalSignedBits(t *testing.T) {
+	assert := assert.New(t)
+
+	// The signed bit-fields are not sign extended
+	var v struct {
+		Low  int8  `tlv:"1" bits:"0-3"`
+		High int16 `tlv:"1" bits:"4-7"`
+	}
+	_, err := Unmarshal(T8L16{1, 0, 1, 0xF8}, &v)
+	assert.NoError(err)
+	assert.Equal(int8(8), v.Low)
+	assert.Equal(int16(15), v.High)
+}
@@ -0,0 +1,90 @@
+package tlv
+
+// This file has support of user defined decoders.
+
+import (
+	"encoding"
+	"reflect"
+	"sync"
+)
+
+// DecoderFunc is the function decoding TLV value data into Go value rv.
+// The rv is settable and is not a pointer.
+type DecoderFunc func(data []byte, rv reflect.Value) error
+
+// RegisterDecoder registers fn as decoder of TLV values for Go type t.
+// The registered decoder takes precedence over built-in decoding and
+// over encoding.BinaryUnmarshaler or encoding.TextUnmarshaler implemented by t.
+// Registering nil fn removes the decoder for t.
+//
+// It is intended to plug in decoders for enums, bitmasks and vendor blobs
+// without wrapping the types.
+func RegisterDecoder(t reflect.Type, fn DecoderFunc) {
+	// The compiled plans might refer to decoder of t
+	defer cachePlan.Clear()
+	defer clearHintPlans()
+
+	if fn == nil {
+		decoders.Delete(t)
+		return
+	}
+	decoders.Store(t, fn)
+}
+
+var decoders sync.Map // map[reflect.Type]DecoderFunc
+
+// BudgetUnmarshaler is the interface implemented by the types,
+// which decode TLV value within Budget of the whole decoding
+// (i.e. generated by cmd/tlvgen).
+// It takes precedence over encoding.BinaryUnmarshaler.
+type BudgetUnmarshaler interface {
+	UnmarshalTLV(data []byte, b *Budget) error
+}
+
+// The unmarshalCustom decodes data to rv either with registered decoder,
+// or with BudgetUnmarshaler, encoding.BinaryUnmarshaler or encoding.TextUnmarshaler
+// (of string types only) of rv.
+// The first return value is false, if rv has none of those.
+// The custom decoder always consumes all of data.
+func unmarshalCustom(data []byte, rv reflect.Value, b *Budget) (bool, []byte, error) {
+	if fn, ok := decoders.Load(rv.Type()); ok {
+		if err := fn.(DecoderFunc)(data, rv); err != nil {
+			return true, data, err
+		}
+		return true, nil, nil
+	}
+
+	// The time.Time implements both unmarshalers,
+	// but TLV has own time format.
+	if isTime(rv) || !rv.CanAddr() {
+		return false, data, nil
+	}
+
+	pv := rv.Addr().Interface()
+	if u, ok := pv.(BudgetUnmarshaler); ok {
+		if err := u.UnmarshalTLV(data, b); err != nil {
+			return true, data, err
+		}
+		return true, nil, nil
+	}
+	if u, ok := pv.(encoding.BinaryUnmarshaler); ok {
+		if err := u.UnmarshalBinary(data); err != nil {
+			return true, data, err
+		}
+		return true, nil, nil
+	}
+	// The text form is applicable to string TLVs only, so neither
+	// the byte slices and arrays (i.e. net.IP), which have binary value,
+	// nor the structs with tags (i.e. parsing own config) are given text.
+	if rv.Kind() != reflect.String {
+		return false, data, nil
+	}
+	if u, ok := pv.(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText(data); err != nil {
+			return true, data, err
+		}
+		return true, nil, nil
+	}
+
+	return false, data, nil
+}
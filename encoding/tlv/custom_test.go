@@ -0,0 +1,107 @@
+package tlv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVersion implements encoding.BinaryUnmarshaler
+type TestVersion struct {
+	Major, Minor byte
+}
+
+func (v *TestVersion) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("bad version length %d", len(data))
+	}
+	v.Major, v.Minor = data[0], data[1]
+	return nil
+}
+
+// TestName implements encoding.TextUnmarshaler
+type TestName string
+
+func (n *TestName) UnmarshalText(text []byte) error {
+	*n = TestName(strings.ToUpper(string(text)))
+	return nil
+}
+
+// TestConfig implements encoding.TextUnmarshaler for own config format,
+// which does not apply to TLVs
+type TestConfig struct {
+	Port uint16 `tlv:"1"`
+}
+
+func (c *TestConfig) UnmarshalText(text []byte) error {
+	return fmt.Errorf("unexpected text %q", text)
+}
+
+// TestMode has registered decoder
+type TestMode uint16
+
+type TestStructCustom struct {
+	Version TestVersion  `tlv:"1"`
+	Name    TestName     `tlv:"2"`
+	Mode    TestMode     `tlv:"3"`
+	Opt     *TestVersion `tlv:"5"`
+	Config  TestConfig   `tlv:"6"`
+}
+
+func TestUnmarshalCustom(t *testing.T) {
+	assert := assert.New(t)
+
+	RegisterDecoder(reflect.TypeOf(TestMode(0)), func(data []byte, rv reflect.Value) error {
+		// The value is a single octet shifted by 8
+		if len(data) != 1 {
+			return fmt.Errorf("bad mode length %d", len(data))
+		}
+		rv.SetUint(uint64(data[0]) << 8)
+		return nil
+	})
+	defer RegisterDecoder(reflect.TypeOf(TestMode(0)), nil)
+
+	data := T8L16{
+		1, 0, 2, 3, 1,
+		2, 0, 3, 'a', 'b', 'c',
+		3, 0, 1, 0x12,
+		5, 0, 2, 4, 0,
+		6, 0, 5, 1, 0, 2, 0, 80,
+	}
+	var v TestStructCustom
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(TestVersion{3, 1}, v.Version)
+	assert.Equal(TestName("ABC"), v.Name)
+	assert.Equal(TestMode(0x1200), v.Mode)
+	if assert.NotNil(v.Opt) {
+		assert.Equal(TestVersion{4, 0}, *v.Opt)
+	}
+	// The tagged struct is decoded by tags, not by UnmarshalText
+	assert.Equal(TestConfig{Port: 80}, v.Config)
+
+	_, err = Unmarshal(T8L16{1, 0, 1, 3}, &v)
+	assert.Error(err)
+	_, err = Unmarshal(T8L16{3, 0, 2, 1, 2}, &v)
+	assert.Error(err)
+}
+
+func TestUnmarshalCustomUnregister(t *testing.T) {
+	assert := assert.New(t)
+
+	RegisterDecoder(reflect.TypeOf(TestMode(0)), func(data []byte, rv reflect.Value) error {
+		rv.SetUint(1)
+		return nil
+	})
+	RegisterDecoder(reflect.TypeOf(TestMode(0)), nil)
+
+	var v TestMode
+	rest, err := Unmarshal(T8L16{0x12, 0x34}, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(TestMode(0x1234), v)
+}
@@ -0,0 +1,131 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestGcpHeader struct {
+	SequenceNumber uint16 `tlv:"10"`
+	Operation      uint8  `tlv:"11"`
+}
+
+type TestGcpResponse struct {
+	ResponseCode uint8  `tlv:"19"`
+	ErrorMessage string `tlv:"20"`
+}
+
+type TestGcpSequence struct {
+	TestGcpHeader
+	*TestGcpResponse
+	CoreName string `tlv:"5"`
+}
+
+type TestEmbedRecursive struct {
+	*TestEmbedRecursive
+	Index uint8 `tlv:"1"`
+}
+
+func TestUnmarshalEmbedded(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{
+		10, 0, 2, 0, 1,
+		11, 0, 1, 7,
+		5, 0, 1, 'a',
+	}
+	var v TestGcpSequence
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(uint16(1), v.SequenceNumber)
+	assert.Equal(uint8(7), v.Operation)
+	assert.Equal("a", v.CoreName)
+	// The pointer to embedded struct is allocated only if needed
+	assert.Nil(v.TestGcpResponse)
+
+	data = append(data, 19, 0, 1, 2, 20, 0, 2, 'n', 'o')
+	v = TestGcpSequence{}
+	_, err = Unmarshal(data, &v)
+	assert.NoError(err)
+	if assert.NotNil(v.TestGcpResponse) {
+		assert.Equal(uint8(2), v.ResponseCode)
+		assert.Equal("no", v.ErrorMessage)
+	}
+
+	// The embedded fields are repeated in strict mode as any others
+	_, err = UnmarshalWithOptions(T8L16{11, 0, 1, 7, 5, 0, 0, 11, 0, 1, 8}, &TestGcpSequence{}, DecodeOptions{Strict: true})
+	assert.ErrorIs(err, ErrDuplicateTLV)
+
+	// The Map given to Unmarshal may refer to promoted fields
+	m := Map{10: {K: "SequenceNumber"}, 19: {K: "ResponseCode"}}
+	v = TestGcpSequence{}
+	_, err = Unmarshal(T8L16{10, 0, 2, 0, 9, 19, 0, 1, 3}, &v, m)
+	assert.NoError(err)
+	assert.Equal(uint16(9), v.SequenceNumber)
+	assert.Equal(uint8(3), v.ResponseCode)
+
+	var r TestEmbedRecursive
+	_, err = Unmarshal(T8L16{1, 0, 1, 4}, &r)
+	assert.NoError(err)
+	assert.Equal(uint8(4), r.Index)
+}
+
+func TestUnmarshalEmbeddedConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	type Other struct {
+		Code uint8 `tlv:"10"`
+	}
+	var both struct {
+		TestGcpHeader
+		Other
+	}
+	_, err := Unmarshal(T8L16{}, &both)
+	var de *DuplicateTLVError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal(byte(10), de.TLV)
+		assert.Equal("tlv 10 is mapped by both fields SequenceNumber and Code", de.Error())
+	}
+
+	// The field of shallower struct wins, as of encoding/json
+	var outer struct {
+		TestGcpHeader
+		Number uint16 `tlv:"10"`
+	}
+	_, err = Unmarshal(T8L16{10, 0, 2, 0, 3, 11, 0, 1, 4}, &outer)
+	assert.NoError(err)
+	assert.Equal(uint16(3), outer.Number)
+	assert.Equal(uint16(0), outer.SequenceNumber)
+	assert.Equal(uint8(4), outer.Operation)
+
+	// The shallower field resolves the ambiguity of embedded structs
+	var resolved struct {
+		TestGcpHeader
+		Other
+		Code uint8 `tlv:"10"`
+	}
+	_, err = Unmarshal(T8L16{10, 0, 1, 5}, &resolved)
+	assert.NoError(err)
+	assert.Equal(uint8(5), resolved.Code)
+
+	// The exported fields of unexported embedded struct are promoted,
+	// but the tagged embedded struct is decoded as any other field
+	type header = TestGcpHeader
+	var promoted struct {
+		header
+		Other `tlv:"1"`
+	}
+	_, err = Unmarshal(T8L16{10, 0, 2, 0, 1, 1, 0, 4, 10, 0, 1, 2}, &promoted)
+	assert.NoError(err)
+	assert.Equal(uint16(1), promoted.SequenceNumber)
+	assert.Equal(uint8(2), promoted.Code)
+
+	// The unexported pointer is not settable, so it is not promoted
+	var hidden struct {
+		*header
+	}
+	_, err = Unmarshal(T8L16{10, 0, 1, 1}, &hidden)
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+}
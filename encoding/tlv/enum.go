@@ -0,0 +1,62 @@
+package tlv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TLVEnum is the interface implemented by Go integer types
+// of enumerated TLV values.
+// TLVEnumValues returns names of all defined values keyed by the value.
+//
+// In strict mode Unmarshal rejects values not defined by TLVEnumValues.
+type TLVEnum interface {
+	TLVEnumValues() map[uint64]string
+}
+
+// EnumString returns the name of e value,
+// or "Type(value)" if the value has no name.
+// It is intended to implement fmt.Stringer of enumerated types.
+func EnumString(e TLVEnum) string {
+	rv := reflect.ValueOf(e)
+	u, ok := enumValue(rv)
+	if !ok {
+		return fmt.Sprintf("%s(?)", rv.Type().Name())
+	}
+	if name, ok := e.TLVEnumValues()[u]; ok {
+		return name
+	}
+	// The value shall not be formatted with %v,
+	// as it may call String() of e
+	if k := rv.Kind(); k >= reflect.Int && k <= reflect.Int64 {
+		return fmt.Sprintf("%s(%d)", rv.Type().Name(), rv.Int())
+	}
+	return fmt.Sprintf("%s(%d)", rv.Type().Name(), u)
+}
+
+// The enumValue returns value of integer rv as uint64.
+func enumValue(rv reflect.Value) (uint64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	}
+	return 0, false
+}
+
+// The checkEnum checks, that rv implementing TLVEnum has defined value.
+func checkEnum(rv reflect.Value) error {
+	e, ok := rv.Interface().(TLVEnum)
+	if !ok {
+		return nil
+	}
+	u, ok := enumValue(rv)
+	if !ok {
+		return nil
+	}
+	if _, ok := e.TLVEnumValues()[u]; !ok {
+		return ErrEnumValueOutOfRange
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package tlv
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCoreMode uint8
+
+func (TestCoreMode) TLVEnumValues() map[uint64]string {
+	return map[uint64]string{1: "Active", 2: "Backup"}
+}
+
+func (m TestCoreMode) String() string {
+	return EnumString(m)
+}
+
+type TestStructEnum struct {
+	Mode TestCoreMode `tlv:"7"`
+}
+
+func TestUnmarshalEnum(t *testing.T) {
+	assert := assert.New(t)
+
+	var v TestStructEnum
+	rest, err := UnmarshalWithOptions(T8L16{7, 0, 1, 2}, &v, DecodeOptions{Strict: true})
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(TestCoreMode(2), v.Mode)
+	assert.Equal("Backup", fmt.Sprint(v.Mode))
+
+	rest, err = Unmarshal(T8L16{7, 0, 1, 9}, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal("TestCoreMode(9)", v.Mode.String())
+
+	_, err = UnmarshalWithOptions(T8L16{7, 0, 1, 9}, &v, DecodeOptions{Strict: true})
+	assert.Error(err)
+	var mode TestCoreMode
+	_, err = UnmarshalWithOptions(T8L16{9}, &mode, DecodeOptions{Strict: true})
+	assert.ErrorIs(err, ErrEnumValueOutOfRange)
+}
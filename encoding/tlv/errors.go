@@ -3,38 +3,144 @@ package tlv
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
+// DecodeError is the error of decoding TLV data.
+// It locates the failed TLV, and wraps the cause,
+// so the cause could be checked with errors.Is and errors.As.
+// The same error is used by package github.com/cloudcopper/core/tlv
+// for YAML and generic TLV structures.
+type DecodeError struct {
+	Offset int      // offset of failed TLV in data, or -1 if not known
+	Path   []int    // TLV types from top level down to failed TLV
+	Names  []string // names of TLVs along Path, if known
+	Line   int      // line of failed YAML node, or 0 if not known
+	Column int      // column of failed YAML node, or 0 if not known
+	Value  string   // offending YAML scalar, if any
+	Err    error    // the cause
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.PathString())
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		fmt.Fprintf(&b, " at line %d, column %d", e.Line, e.Column)
+	case e.Line > 0:
+		fmt.Fprintf(&b, " at line %d", e.Line)
+	case e.Offset >= 0:
+		fmt.Fprintf(&b, " at offset %d", e.Offset)
+	}
+	cause := e.Err.Error()
+	if e.Value != "" {
+		cause += " " + strconv.Quote(e.Value)
+	}
+	if b.Len() == 0 {
+		return cause
+	}
+	return strings.TrimPrefix(b.String(), " ") + ": " + cause
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// PathString returns path of failed TLV as "Name(T)/Name(T)/T".
+func (e *DecodeError) PathString() string {
+	var b strings.Builder
+	for i, t := range e.Path {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		if i < len(e.Names) && e.Names[i] != "" {
+			fmt.Fprintf(&b, "%s(%d)", e.Names[i], t)
+			continue
+		}
+		b.WriteString(strconv.Itoa(t))
+	}
+	return b.String()
+}
+
+// WrapDecodeError locates err of TLV type t at offset of the TLV within its parent,
+// for decoders of nested TLVs (i.e. generated by cmd/tlvgen).
+// The *DecodeError of the TLV value is located within the parent:
+// t is prepended to its Path, and the offset of value is added to its Offset.
+// Any other error is wrapped to DecodeError of TLV t.
+func WrapDecodeError(err error, t byte, offset int) error {
+	de, ok := err.(*DecodeError)
+	if !ok {
+		return &DecodeError{Offset: offset, Path: []int{int(t)}, Err: err}
+	}
+	e := *de
+	e.Path = append([]int{int(t)}, de.Path...)
+	if len(de.Names) > 0 {
+		e.Names = append([]string{""}, de.Names...)
+	}
+	if e.Offset >= 0 {
+		e.Offset += offset + 3
+	}
+	return &e
+}
+
 // WrongKindError is the error returned when wrong reflect.Kind being detected.
 type WrongKindError struct {
-	kind reflect.Kind
-	path []byte
+	Kind reflect.Kind
 }
 
 func (e *WrongKindError) Error() string {
-	if e.path == nil {
-		return fmt.Sprintf("wrong kind %v", e.kind)
-	}
-	return fmt.Sprintf("wrong kind %v, path %v", e.kind, e.path)
+	return fmt.Sprintf("wrong kind %v", e.Kind)
 }
 
 // UnprocessedDataError is the error returned when there is unprocessed data
 type UnprocessedDataError struct {
-	data []byte
+	Data []byte
 }
 
 func (e *UnprocessedDataError) Error() string {
-	return fmt.Sprintf("unprocessed data %v", e.data)
+	return fmt.Sprintf("unprocessed data %v", e.Data)
+}
+
+// LimitError is the error returned when data exceeds the decoding limits.
+// It is ErrLimitExceeded for errors.Is.
+type LimitError struct {
+	Limit string // the exceeded limit, i.e. "depth"
+	Max   int    // the value of limit
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: max %s %d", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+func (e *LimitError) Unwrap() error { return ErrLimitExceeded }
+
+// PathMapKeyError is the error returned when the key of PathMap is not dotted TLV types
+type PathMapKeyError struct {
+	Key string
+}
+
+func (e *PathMapKeyError) Error() string {
+	return fmt.Sprintf("bad path map key %q", e.Key)
+}
+
+// DuplicateTLVError is the error returned when the same TLV type
+// is mapped by fields of different embedded structs of the same depth.
+type DuplicateTLVError struct {
+	TLV    byte
+	Fields [2]string
+}
+
+func (e *DuplicateTLVError) Error() string {
+	return fmt.Sprintf("tlv %d is mapped by both fields %s and %s", e.TLV, e.Fields[0], e.Fields[1])
 }
 
 // ReflectValueHasNoFieldError is the error returned when the reflect.Value has no field
 type ReflectValueHasNoFieldError struct {
-	rv    reflect.Value
-	field string
+	Type  reflect.Type
+	Field string
 }
 
 func (e *ReflectValueHasNoFieldError) Error() string {
-	return fmt.Sprintf("reflect value of type %v has no filed %v", e.rv.Type().Name(), e.field)
+	return fmt.Sprintf("reflect value of type %v has no field %v", e.Type.Name(), e.Field)
 }
 
 // Error type is a string to allow const errors within this package
@@ -58,14 +164,42 @@ const ErrReflectValueIsNotSettable = Error("reflect value is not settable")
 const ErrNoTlvMap = Error("no tlv map")
 
 // ErrTlvMapHasNoEntry is the error when the TLV Map has no entry for TLV Type
-type ErrTlvMapHasNoEntry struct {
-	Path []byte
-}
-
-func (e ErrTlvMapHasNoEntry) Error() string {
-	return fmt.Sprintf("tlv map has no entry - %v", e.Path)
-
-}
+const ErrTlvMapHasNoEntry = Error("tlv map has no entry")
 
+// ErrNotEnoughData is the error when value data is shorter than the target type
 const ErrNotEnoughData = Error("not enough data")
+
+// ErrBadTime is the error when value data is not valid DateAndTime,
+// i.e. day 31 of February, or year out of 1..9999
 const ErrBadTime = Error("bad time")
+
+// ErrValueTooLong is the error when value data is longer than the target type in strict mode,
+// or the value does not fit 16 bits length of TLV
+const ErrValueTooLong = Error("value too long")
+
+// ErrDuplicateTLV is the error when TLV of non-slice field is repeated in strict mode
+const ErrDuplicateTLV = Error("duplicate tlv")
+
+// ErrBadBitsTag is the error when struct tag `bits` is malformed or does not fit the field
+const ErrBadBitsTag = Error("bad bits struct tag")
+
+// ErrEnumValueOutOfRange is the error when value of TLVEnum is not defined in strict mode
+const ErrEnumValueOutOfRange = Error("enum value out of range")
+
+// ErrBadVendorTag is the error when struct tag `vendor` is malformed or the field is not VendorSpecific
+const ErrBadVendorTag = Error("bad vendor struct tag")
+
+// ErrNoVendorID is the error when vendor-specific TLV does not start with vendor ID
+const ErrNoVendorID = Error("no vendor id")
+
+// ErrTagMismatch is the error when dotted struct tag does not match the path of TLV
+const ErrTagMismatch = Error("struct tag does not match path")
+
+// ErrBadTagOption is the error when `tlv` struct tag has unknown or malformed option
+const ErrBadTagOption = Error("bad struct tag option")
+
+// ErrNoMapKey is the error when TLV of keyed map item has no key TLV
+const ErrNoMapKey = Error("no map key")
+
+// ErrLimitExceeded is the error when data exceeds Limits of DecodeOptions
+const ErrLimitExceeded = Error("limit exceeded")
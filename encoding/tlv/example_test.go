@@ -11,7 +11,8 @@ import (
 // if input data has such element.
 // Explicit map is not needed, as it taken from fields struct tags.
 // The struct tag has form `tlv:"1.2.3.4"` but only last octet has meaning.
-// Leading octects are for documenting purpose and may even be not a digit.
+// Leading octects are for documenting purpose and may even be not a digit,
+// but are checked against the path of TLV with DecodeOptions.CheckTags.
 func ExampleUnmarshal() {
 	type Struct struct {
 		A uint16  `tlv:"1.1"`
@@ -295,3 +296,34 @@ func ExampleUnmarshal_sliceOfBasicType() {
 	// value[2] 0x5566
 	// value[3] 0x7788
 }
+
+// Forward TLV data with modified known field and untouched unknown TLVs.
+// The unknown TLVs are kept by field of type RawList with own positions,
+// so those are merged back in place.
+func ExampleRawList_Merge() {
+	type Struct struct {
+		A       uint8 `tlv:"1"`
+		B       uint8 `tlv:"2"`
+		Unknown RawList
+	}
+
+	data := T8L16{
+		1, 0, 1, 0x01,
+		99, 0, 2, 0xCA, 0xFE, // <- the vendor extension
+		2, 0, 1, 0x02,
+	}
+
+	var v Struct
+	_, err := Unmarshal(data, &v)
+	fmt.Printf("err %v\n", err)
+	fmt.Printf("unknown %v\n", v.Unknown)
+
+	v.A++
+	a, _ := AppendT8L16(nil, 1, []byte{v.A})
+	b, _ := AppendT8L16(nil, 2, []byte{v.B})
+	fmt.Printf("data %v\n", v.Unknown.Merge(a, b))
+	// Output:
+	// err <nil>
+	// unknown [{1 [99 0 2 202 254]}]
+	// data [1 0 1 2 99 0 2 202 254 2 0 1 2]
+}
@@ -0,0 +1,131 @@
+package tlv
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// The fuzz targets check, that hostile data never panics the decoders,
+// and that the errors locate failed TLV within data.
+// The seeds are run as tests, "go test -fuzz=FuzzUnmarshal" runs the fuzzing.
+
+type fuzzStruct struct {
+	Bool     bool                     `tlv:"1"`
+	Int8     int8                     `tlv:"2"`
+	Int16    int16                    `tlv:"3"`
+	Int32    int32                    `tlv:"4"`
+	Int      int                      `tlv:"5"`
+	Uint16   uint16                   `tlv:"6"`
+	Uint64   *uint64                  `tlv:"7"`
+	Float32  float32                  `tlv:"8"`
+	Float64  float64                  `tlv:"9"`
+	String   string                   `tlv:"10"`
+	Bytes    []byte                   `tlv:"11"`
+	IP       net.IP                   `tlv:"12"`
+	Array    [4]byte                  `tlv:"13"`
+	Time     *time.Time               `tlv:"14"`
+	Uints    []uint16                 `tlv:"15"`
+	Mode     TestCoreMode             `tlv:"16"`
+	Function TestCoreFunction         `tlv:"17"`
+	Enable   bool                     `tlv:"18" bits:"0"`
+	Priority uint8                    `tlv:"18" bits:"3-5"`
+	Version  *TestVersion             `tlv:"19"`
+	Name     TestName                 `tlv:"20"`
+	Nested   *nested                  `tlv:"21"`
+	Items    []TestStruct6            `tlv:"22"`
+	Channels map[uint8]TestMapChannel `tlv:"23,key=12"`
+	Ports    map[byte]uint16          `tlv:"24"`
+	Others   []interface{}            `tlv:"others"`
+}
+
+func fuzzSeeds(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 0, 1, 1, 2, 0, 1, 0xFF, 3, 0, 1, 0x80})
+	f.Add([]byte{14, 0, 8, 0x07, 0xE9, 11, 29, 8, 30, 22, 7})
+	f.Add([]byte{14, 0, 11, 0x07, 0xE9, 11, 29, 8, 30, 22, 7, '-', 3, 30})
+	f.Add([]byte{15, 0, 5, 0, 1, 0, 2, 3, 16, 0, 1, 2, 17, 0, 2, 0x10, 0x01})
+	f.Add([]byte{21, 0, 9, 1, 0, 6, 1, 0, 3, 2, 0, 0})
+	f.Add([]byte{22, 0, 8, 1, 0, 1, 1, 4, 0, 1, 2})
+	f.Add([]byte{99, 0, 2, 1, 2, 8, 0, 3, 1, 2, 3})
+	f.Add([]byte{1, 0xFF, 0xFF, 1})
+	f.Add([]byte(benchMessageData(2)))
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var unknown []UnknownTLV
+		for _, opts := range []DecodeOptions{{}, {Strict: true, Unknown: &unknown}, {Limits: Limits{MaxDepth: 4, MaxElements: 16}}} {
+			var v fuzzStruct
+			rest, err := UnmarshalWithOptions(data, &v, opts)
+			checkFuzzError(t, data, rest, err)
+
+			var m benchMessage
+			rest, err = UnmarshalWithOptions(data, &m, opts)
+			checkFuzzError(t, data, rest, err)
+		}
+	})
+}
+
+func FuzzUnmarshalInterface(f *testing.F) {
+	fuzzSeeds(f)
+	hint := Map{
+		5:         {T: reflect.TypeOf(TestStruct5{})},
+		21:        {T: reflect.TypeOf(nested{})},
+		AllOthers: {T: reflect.TypeOf(T8L16{})},
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v []interface{}
+		rest, err := Unmarshal(data, &v, hint)
+		checkFuzzError(t, data, rest, err)
+
+		var i interface{}
+		rest, err = Unmarshal(data, &i, hint)
+		checkFuzzError(t, data, rest, err)
+	})
+}
+
+func FuzzRead(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, v, rest, err := T8L16(data).Read()
+		if err == nil && 3+len(v)+len(rest) != len(data) {
+			t.Fatalf("read %d+%d octets out of %d", len(v), len(rest), len(data))
+		}
+	})
+}
+
+func FuzzDecodeValue(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, size := range []int{1, 2, 4, 8} {
+			u, err := DecodeUint(data, size)
+			if err == nil && len(data) == size && !reflect.DeepEqual(AppendUint(nil, u, size), data) {
+				t.Fatalf("uint %d of %v", u, data)
+			}
+			_, _ = DecodeInt(data, size)
+		}
+	})
+}
+
+// The checkFuzzError checks, that rest is tail of data,
+// and that err locates failed TLV within data.
+func checkFuzzError(t *testing.T, data []byte, rest []byte, err error) {
+	t.Helper()
+	if len(rest) > len(data) {
+		t.Fatalf("rest %d octets out of %d", len(rest), len(data))
+	}
+	if err == nil {
+		return
+	}
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("error is not DecodeError: %v", err)
+	}
+	if de.Offset < -1 || de.Offset > len(data) {
+		t.Fatalf("error offset %d out of data %d: %v", de.Offset, len(data), err)
+	}
+}
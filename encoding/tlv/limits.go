@@ -0,0 +1,110 @@
+package tlv
+
+// This file has limits of decoding untrusted data.
+
+// Limits bounds the resources used to decode untrusted data (i.e. from network peers).
+// The zero value of field means no limit.
+// The exceeded limit fails decoding with LimitError, which is ErrLimitExceeded.
+type Limits struct {
+	MaxDepth     int // maximal nesting depth of TLVs, the top level TLV has depth 1
+	MaxElements  int // maximal number of TLVs in data
+	MaxBytes     int // maximal size of data
+	MaxChildren  int // maximal number of TLVs within single TLV, or at top level
+	MaxSliceLen  int // maximal number of items of decoded slice
+	MaxValues    int // maximal number of Go values allocated by decoding (slice items and pointers)
+	MaxStringLen int // maximal length of decoded string
+}
+
+// Budget tracks the use of Limits by single decoding.
+// It is used by Unmarshal and by decoders generated by cmd/tlvgen,
+// which are given the same Budget for the nested TLVs.
+// The nil Budget has no limits.
+type Budget struct {
+	limits   Limits
+	depth    int   // the nesting depth of current TLV
+	elements int   // the number of TLVs entered so far
+	values   int   // the number of Go values allocated so far
+	children []int // the number of TLVs entered within each open TLV, by depth
+}
+
+// NewBudget returns budget of limits.
+func NewBudget(limits Limits) *Budget {
+	return &Budget{limits: limits}
+}
+
+// Bytes checks size n of data to be decoded.
+func (b *Budget) Bytes(n int) error {
+	if b == nil {
+		return nil
+	}
+	return check("bytes", n, b.limits.MaxBytes)
+}
+
+// Enter enters TLV. It checks the depth, the number of TLVs and the number of TLVs
+// within the parent. The entered TLV must be left by Leave, unless decoding fails.
+func (b *Budget) Enter() error {
+	if b == nil {
+		return nil
+	}
+	b.depth++
+	b.elements++
+	if err := check("depth", b.depth, b.limits.MaxDepth); err != nil {
+		return err
+	}
+	if err := check("elements", b.elements, b.limits.MaxElements); err != nil {
+		return err
+	}
+	if b.limits.MaxChildren == 0 {
+		return nil
+	}
+	for len(b.children) < b.depth {
+		b.children = append(b.children, 0)
+	}
+	b.children[b.depth-1]++
+	return check("children", b.children[b.depth-1], b.limits.MaxChildren)
+}
+
+// Leave leaves the TLV entered by Enter.
+func (b *Budget) Leave() {
+	if b == nil {
+		return
+	}
+	// The children of left TLV are counted anew within the next one
+	if b.depth < len(b.children) {
+		b.children[b.depth] = 0
+	}
+	b.depth--
+}
+
+// Value counts Go value allocated by decoding.
+func (b *Budget) Value() error {
+	if b == nil {
+		return nil
+	}
+	b.values++
+	return check("values", b.values, b.limits.MaxValues)
+}
+
+// SliceLen checks length n of decoded slice.
+func (b *Budget) SliceLen(n int) error {
+	if b == nil {
+		return nil
+	}
+	return check("slice length", n, b.limits.MaxSliceLen)
+}
+
+// StringLen checks length n of decoded string.
+func (b *Budget) StringLen(n int) error {
+	if b == nil {
+		return nil
+	}
+	return check("string length", n, b.limits.MaxStringLen)
+}
+
+// The check returns LimitError, if n exceeds limit max
+func check(limit string, n, max int) error {
+	if max > 0 && n > max {
+		return &LimitError{Limit: limit, Max: max}
+	}
+	return nil
+}
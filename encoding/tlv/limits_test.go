@@ -0,0 +1,132 @@
+package tlv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The nested is recursive type, so its depth is given by data only
+type nested struct {
+	Child *nested `tlv:"1"`
+	Value []uint8 `tlv:"2"`
+}
+
+// The nest returns data of n nested TLVs of type 1 with value v inside
+func nest(n int, v T8L16) T8L16 {
+	data := v
+	for i := 0; i < n; i++ {
+		data, _ = AppendT8L16(nil, 1, data)
+	}
+	return data
+}
+
+func TestLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	data := nest(10, T8L16{2, 0, 3, 7, 8, 9})
+	var v nested
+	_, err := UnmarshalWithOptions(data, &v, DecodeOptions{Limits: Limits{MaxDepth: 11, MaxElements: 11, MaxBytes: len(data)}})
+	assert.NoError(err)
+
+	v = nested{}
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{Limits: Limits{MaxDepth: 5}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+	var le *LimitError
+	if assert.ErrorAs(err, &le) {
+		assert.Equal("depth", le.Limit)
+		assert.Equal(5, le.Max)
+	}
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{1, 1, 1, 1, 1, 1}, de.Path)
+		assert.Equal(15, de.Offset)
+	}
+
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{Limits: Limits{MaxElements: 10}})
+	assert.True(errors.As(err, &le))
+	assert.Equal("elements", le.Limit)
+
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{Limits: Limits{MaxBytes: 10}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+	assert.EqualError(err, "at offset 0: limit exceeded: max bytes 10")
+}
+
+// The decoded []byte must not overwrite the rest of data, when appended to
+func TestBytesCapacity(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{2, 0, 1, 7, 2, 0, 1, 8}
+	var s struct {
+		Raw []byte `tlv:"2"`
+	}
+	_, err := Unmarshal(data[:4], &s)
+	assert.NoError(err)
+	s.Raw = append(s.Raw, 0xFF)
+	assert.Equal(T8L16{2, 0, 1, 7, 2, 0, 1, 8}, data)
+}
+
+func TestLimitsValues(t *testing.T) {
+	assert := assert.New(t)
+
+	type item struct {
+		A uint8 `tlv:"1"`
+	}
+	type root struct {
+		Items []item   `tlv:"5"`
+		Uints []uint16 `tlv:"6"`
+		Name  string   `tlv:"7"`
+		Ptr   *uint8   `tlv:"8"`
+	}
+	data := T8L16{5, 0, 4, 1, 0, 1, 1, 5, 0, 4, 1, 0, 1, 2, 5, 0, 4, 1, 0, 1, 3, 6, 0, 6, 0, 1, 0, 2, 0, 3, 7, 0, 3, 'a', 'b', 'c', 8, 0, 1, 9}
+
+	var v root
+	_, err := UnmarshalWithOptions(data, &v, DecodeOptions{Limits: Limits{MaxChildren: 6, MaxSliceLen: 3, MaxValues: 7, MaxStringLen: 3}})
+	assert.NoError(err)
+	assert.Len(v.Items, 3)
+	assert.Equal([]uint16{1, 2, 3}, v.Uints)
+
+	for _, c := range []struct {
+		limits Limits
+		err    string
+	}{
+		{Limits{MaxChildren: 5}, "Ptr(8) at offset 36: limit exceeded: max children 5"},
+		{Limits{MaxSliceLen: 2}, "Items(5) at offset 14: limit exceeded: max slice length 2"},
+		{Limits{MaxValues: 5}, "Uints(6) at offset 21: limit exceeded: max values 5"},
+		{Limits{MaxStringLen: 2}, "Name(7) at offset 30: limit exceeded: max string length 2"},
+	} {
+		v = root{}
+		_, err = UnmarshalWithOptions(data, &v, DecodeOptions{Limits: c.limits})
+		assert.ErrorIs(err, ErrLimitExceeded)
+		assert.EqualError(err, c.err)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	// The children are counted within each parent
+	b := NewBudget(Limits{MaxChildren: 2})
+	assert.NoError(b.Enter()) // 1
+	assert.NoError(b.Enter()) // 1/1
+	assert.NoError(b.Enter()) // 1/1/1
+	b.Leave()
+	assert.NoError(b.Enter()) // 1/1/2
+	b.Leave()
+	assert.ErrorIs(b.Enter(), ErrLimitExceeded) // 1/1/3
+	b.Leave()
+	b.Leave()
+	assert.NoError(b.Enter()) // 1/2
+	assert.NoError(b.Enter()) // 1/2/1
+	b.Leave()
+	b.Leave()
+	b.Leave()
+	assert.NoError(b.Enter()) // 2
+
+	// The nil budget has no limits
+	var nb *Budget
+	assert.NoError(nb.Enter())
+	assert.NoError(nb.Value())
+	nb.Leave()
+}
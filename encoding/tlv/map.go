@@ -0,0 +1,117 @@
+package tlv
+
+// This file has decoding of TLVs to Go maps.
+
+import (
+	"reflect"
+)
+
+// The unmarshalMap decodes TLVs of data to map rv keyed by TLV type,
+// i.e. map[byte]T or map[byte][]byte.
+func (d *decodeState) unmarshalMap(data T8L16, rv reflect.Value) ([]byte, error) {
+	if rv.Type().Key().Kind() != reflect.Uint8 {
+		return data, &WrongKindError{Kind: rv.Type().Key().Kind()}
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+
+	for len(data) > 0 {
+		t, v, rest, err := data.Read()
+		if err != nil {
+			return data, d.fail(data, err)
+		}
+		if err := d.push(t, ""); err != nil {
+			return data, d.fail(data, err)
+		}
+
+		key := reflect.ValueOf(t).Convert(rv.Type().Key())
+		if err := d.setMapIndex(rv, key, v); err != nil {
+			return data, d.fail(data, err)
+		}
+		d.pop()
+		data = rest
+	}
+	return nil, nil
+}
+
+// The keyedMapDecoder returns decoder of map items keyed by value of TLV key,
+// which is within the item, i.e. map[uint8]RfChannel with tag `tlv:"16,key=12"`.
+// The key TLV is decoded to the item too, if the item has field for it,
+// otherwise the item is decoded without the key TLV.
+func keyedMapDecoder(key byte) decodeFunc {
+	return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+
+		// The key is decoded out of own TLV within the item
+		kv, found, off := []byte(nil), false, 0
+		for rest := data; len(rest) > 0 && !found; {
+			var t byte
+			var err error
+			off = len(data) - len(rest)
+			if t, kv, rest, err = rest.Read(); err != nil {
+				return data, err
+			}
+			found = t == key
+		}
+		if !found {
+			return data, ErrNoMapKey
+		}
+		k := reflect.New(rv.Type().Key()).Elem()
+		left, err := d.unmarshal(kv, k, nil)
+		if err == nil && len(left) != 0 {
+			err = &UnprocessedDataError{Data: left}
+		}
+		if err != nil {
+			return data, err
+		}
+
+		if hasKeyField(rv.Type().Elem(), key) {
+			return nil, d.setMapIndex(rv, k, data)
+		}
+		// The parts are subslices of data, so the errors have offsets of input
+		return nil, d.setMapIndex(rv, k, data[:off], data[off+3+len(kv):])
+	}
+}
+
+// The hasKeyField tells if items of type t decode the key TLV,
+// i.e. those are not structs of tags, or structs with field for key or AllOthers.
+func hasKeyField(t reflect.Type, key byte) bool {
+	if t.Kind() != reflect.Struct || hasCustomDecoder(t) || getMapper(t) != nil {
+		return true
+	}
+	p, err := getPlan(t)
+	return err != nil || p.unmarshaler || p.fields[key] != nil || p.fields[AllOthers] != nil
+}
+
+// The setMapIndex decodes value v to new item of map rv with key.
+// The value may be given in parts, which are decoded to the same item.
+func (d *decodeState) setMapIndex(rv, key reflect.Value, v ...T8L16) error {
+	// The repeated key overwrites the item, so it is not counted by limits
+	switch {
+	case !rv.MapIndex(key).IsValid():
+		if err := d.appendValue(rv); err != nil {
+			return err
+		}
+	case d.opts.Strict:
+		return ErrDuplicateTLV
+	}
+
+	item := reflect.New(rv.Type().Elem()).Elem()
+	for _, part := range v {
+		if len(part) == 0 && len(v) > 1 {
+			continue
+		}
+		left, err := d.unmarshal(part, item, nil)
+		if err == nil && len(left) != 0 {
+			err = &UnprocessedDataError{Data: left}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	rv.SetMapIndex(key, item)
+	return nil
+}
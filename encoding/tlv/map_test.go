@@ -0,0 +1,137 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestMapChannel struct {
+	Index uint8  `tlv:"12"`
+	Name  string `tlv:"5"`
+}
+
+type TestMapRpd struct {
+	Channels map[uint8]TestMapChannel `tlv:"16,key=12"`
+	Ports    map[byte]uint16          `tlv:"17"`
+	Others   map[byte][]byte          `tlv:"others"`
+}
+
+func TestUnmarshalMap(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{
+		16, 0, 8, 5, 0, 1, 'a', 12, 0, 1, 3,
+		16, 0, 4, 12, 0, 1, 1,
+		17, 0, 9, 1, 0, 2, 0, 80, 2, 0, 1, 1,
+		99, 0, 2, 1, 2,
+		98, 0, 0,
+	}
+	var v TestMapRpd
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(map[uint8]TestMapChannel{
+		3: {Index: 3, Name: "a"},
+		1: {Index: 1},
+	}, v.Channels)
+	assert.Equal(map[byte]uint16{1: 80, 2: 1}, v.Ports)
+	assert.Equal(map[byte][]byte{99: {1, 2}, 98: {}}, v.Others)
+
+	// The map is the top level value too
+	var m map[byte][]byte
+	_, err = Unmarshal(data, &m)
+	assert.NoError(err)
+	assert.Len(m, 4)
+	assert.Equal([]byte{1, 2}, m[99])
+
+	// The keys are TLV types
+	var bad map[string][]byte
+	_, err = Unmarshal(data, &bad)
+	var wk *WrongKindError
+	assert.ErrorAs(err, &wk)
+
+	// The keyed item has the key
+	v = TestMapRpd{}
+	_, err = Unmarshal(T8L16{16, 0, 4, 5, 0, 1, 'a'}, &v)
+	assert.ErrorIs(err, ErrNoMapKey)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{16}, de.Path)
+	}
+
+	// The item of map is located by path
+	_, err = Unmarshal(T8L16{17, 0, 6, 1, 0, 3, 1, 2, 3}, &v)
+	var ud *UnprocessedDataError
+	assert.ErrorAs(err, &ud)
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{17, 1}, de.Path)
+		assert.Equal(3, de.Offset)
+	}
+
+	// The repeated keys are overwritten, or are error in strict mode
+	data = T8L16{16, 0, 4, 12, 0, 1, 1, 16, 0, 8, 12, 0, 1, 1, 5, 0, 1, 'b'}
+	v = TestMapRpd{}
+	_, err = Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Equal(map[uint8]TestMapChannel{1: {Index: 1, Name: "b"}}, v.Channels)
+	_, err = UnmarshalWithOptions(data, &TestMapRpd{}, DecodeOptions{Strict: true})
+	assert.ErrorIs(err, ErrDuplicateTLV)
+
+	// The map items are within limits
+	_, err = UnmarshalWithOptions(data, &TestMapRpd{}, DecodeOptions{Limits: Limits{MaxSliceLen: 1}})
+	assert.NoError(err)
+	_, err = UnmarshalWithOptions(T8L16{17, 0, 6, 1, 0, 0, 2, 0, 0}, &TestMapRpd{}, DecodeOptions{Limits: Limits{MaxSliceLen: 1}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+}
+
+func TestUnmarshalMapWithoutKeyField(t *testing.T) {
+	assert := assert.New(t)
+
+	type channel struct {
+		Name  string `tlv:"5"`
+		Power uint8  `tlv:"6"`
+	}
+	var v struct {
+		Channels map[uint8]channel `tlv:"16,key=12"`
+	}
+
+	// The key TLV is not decoded to item, wherever it is
+	data := T8L16{
+		16, 0, 8, 12, 0, 1, 3, 5, 0, 1, 'a',
+		16, 0, 12, 5, 0, 1, 'b', 12, 0, 1, 1, 6, 0, 1, 9,
+		16, 0, 4, 12, 0, 1, 2,
+	}
+	_, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Equal(map[uint8]channel{
+		3: {Name: "a"},
+		1: {Name: "b", Power: 9},
+		2: {},
+	}, v.Channels)
+
+	// The errors have offsets of input anyway
+	_, err = Unmarshal(T8L16{16, 0, 12, 5, 0, 1, 'b', 12, 0, 1, 1, 7, 0, 1, 9}, &v)
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{16, 7}, de.Path)
+		assert.Equal(11, de.Offset)
+	}
+}
+
+func TestMapTag(t *testing.T) {
+	assert := assert.New(t)
+
+	var notMap struct {
+		Channel TestMapChannel `tlv:"16,key=12"`
+	}
+	_, err := Unmarshal(T8L16{}, &notMap)
+	assert.ErrorIs(err, ErrBadTagOption)
+
+	var unknown struct {
+		Channels map[uint8]TestMapChannel `tlv:"16,index=12"`
+	}
+	_, err = Unmarshal(T8L16{}, &unknown)
+	assert.ErrorIs(err, ErrBadTagOption)
+}
@@ -0,0 +1,140 @@
+package tlv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestRfChannel struct {
+	Index   uint8         `tlv:"12"`
+	Configs []interface{} `tlv:"others"`
+}
+
+func (TestRfChannel) TLVMap(field string) Map {
+	if field != "Configs" {
+		return nil
+	}
+	return Map{
+		62: {K: "DsScQam", T: reflect.TypeOf(TestDsScQam{})},
+		63: {K: "DsOfdm", T: reflect.TypeOf(TestDsOfdm{})},
+	}
+}
+
+type TestDsScQam struct {
+	Frequency uint32      `tlv:"2"`
+	Profile   interface{} `tlv:"3"`
+}
+
+func (*TestDsScQam) TLVMap(field string) Map {
+	return Map{
+		1: {K: "Annex", T: reflect.TypeOf(uint8(0))},
+		2: {K: "Name", T: reflect.TypeOf("")},
+	}
+}
+
+type TestDsOfdm struct {
+	Subcarriers uint16 `tlv:"4"`
+}
+
+type TestRpd struct {
+	Channels []TestRfChannel `tlv:"16"`
+}
+
+func TestUnmarshalMapper(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{
+		16, 0, 29,
+		12, 0, 1, 1,
+		62, 0, 14, 2, 0, 4, 0x23, 0xC3, 0x46, 0x00, 3, 0, 4, 2, 0, 1, 'a',
+		63, 0, 5, 4, 0, 2, 0x10, 0x00,
+		16, 0, 4, 12, 0, 1, 2,
+	}
+	var v TestRpd
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal([]TestRfChannel{
+		{Index: 1, Configs: []interface{}{
+			TestDsScQam{Frequency: 600000000, Profile: "a"},
+			TestDsOfdm{Subcarriers: 4096},
+		}},
+		{Index: 2},
+	}, v.Channels)
+
+	// The TLV not in the map of field is located by path,
+	// where the TLV given to others field is its own item
+	data = T8L16{16, 0, 11, 62, 0, 8, 3, 0, 5, 9, 0, 2, 0, 1}
+	v = TestRpd{}
+	_, err = Unmarshal(data, &v)
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{16, 62, 62, 3, 9}, de.Path)
+		assert.Equal([]string{"Channels", "Configs", "DsScQam", "Profile", ""}, de.Names)
+		assert.Equal(9, de.Offset)
+	}
+
+	// The unknown TLV could be skipped, and TLVs of others field are reported too
+	var unknown []UnknownTLV
+	v = TestRpd{}
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{Unknown: &unknown})
+	assert.NoError(err)
+	assert.Equal([]interface{}{TestDsScQam{}}, v.Channels[0].Configs)
+	if assert.Len(unknown, 2) {
+		assert.Equal([]int{16, 62}, unknown[0].Path)
+		assert.Equal([]int{16, 62, 62, 3, 9}, unknown[1].Path)
+	}
+}
+
+// TestCountedMapper counts calls of TLVMap
+type TestCountedMapper struct {
+	Index uint8       `tlv:"1"`
+	Value interface{} `tlv:"2"`
+}
+
+var testCountedMapperCalls int
+
+func (TestCountedMapper) TLVMap(field string) Map {
+	testCountedMapperCalls++
+	return Map{3: {K: "Name", T: reflect.TypeOf("")}}
+}
+
+func TestUnmarshalMapperHintPlan(t *testing.T) {
+	assert := assert.New(t)
+
+	// The plan of Map given to Unmarshal is cached by content of Map
+	clearHintPlans()
+	testCountedMapperCalls = 0
+	data := T8L16{1, 0, 1, 7, 2, 0, 4, 3, 0, 1, 'a'}
+	for i := 0; i < 3; i++ {
+		var v TestCountedMapper
+		_, err := Unmarshal(data, &v, Map{1: {K: "Index"}, 2: {K: "Value"}})
+		assert.NoError(err)
+		assert.Equal(TestCountedMapper{Index: 7, Value: "a"}, v)
+	}
+	assert.Equal(1, testCountedMapperCalls)
+
+	// The changed Map has own plan
+	m := Map{1: {K: "Index"}, 2: {K: "Value"}}
+	var v TestCountedMapper
+	_, err := Unmarshal(data, &v, m)
+	assert.NoError(err)
+	m[2] = MapEntry{K: "Index"}
+	_, err = Unmarshal(T8L16{2, 0, 1, 9}, &v, m)
+	assert.NoError(err)
+	assert.Equal(uint8(9), v.Index)
+
+	// The cache is bounded
+	for i := 1; i < 256; i++ {
+		for _, k := range []string{"Index", "Value"} {
+			getHintPlan(reflect.TypeOf(v), Map{byte(i): {K: k}})
+			assert.LessOrEqual(numHintPlans.Load(), int64(maxHintPlans))
+		}
+	}
+	n := 0
+	cacheHintPlan.Range(func(_, _ any) bool { n++; return true })
+	assert.LessOrEqual(n, maxHintPlans)
+}
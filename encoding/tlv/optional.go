@@ -0,0 +1,82 @@
+package tlv
+
+// This file has the Go value of optional TLV.
+
+import (
+	"reflect"
+)
+
+// Optional is the Go value of optional TLV, which tells apart the absent TLV,
+// the empty TLV and the TLV with value without allocation of pointer,
+// i.e. RCP read request has empty TLVs of values to be returned.
+// The zero Optional is the absent TLV.
+//
+//	Name tlv.Optional[string] `tlv:"1"`
+//
+// Unmarshal sets Present for each TLV decoded to Optional, and either Empty
+// for the TLV without value or Value decoded as the field of type T would be.
+type Optional[T any] struct {
+	Present bool // the TLV is in data
+	Empty   bool // the TLV is present without value
+	Value   T    // the value of present and non-empty TLV
+}
+
+// NewOptional returns Optional of present TLV with value v
+func NewOptional[T any](v T) Optional[T] {
+	return Optional[T]{Present: true, Value: v}
+}
+
+// NewEmpty returns Optional of present TLV without value
+func NewEmpty[T any]() Optional[T] {
+	return Optional[T]{Present: true, Empty: true}
+}
+
+// Get returns the value and true, if the TLV is present with value
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present && !o.Empty
+}
+
+// AppendT8L16 appends TLV of type t to dst as Optional tells:
+// nothing for absent TLV, TLV without value for empty TLV,
+// or TLV with value encoded by encode (i.e. AppendUint) otherwise.
+// It is intended for encoders (i.e. MarshalBinary generated by cmd/tlvgen).
+func (o Optional[T]) AppendT8L16(dst []byte, t byte, encode func(dst []byte, v T) []byte) ([]byte, error) {
+	switch {
+	case !o.Present:
+		return dst, nil
+	case o.Empty:
+		return AppendT8L16(dst, t, nil)
+	}
+	return AppendT8L16(dst, t, encode(nil, o.Value))
+}
+
+// The optional is implemented by pointers to Optional of any type
+type optional interface {
+	// setPresent marks the TLV present, and returns the value to be decoded
+	setPresent(empty bool) reflect.Value
+}
+
+func (o *Optional[T]) setPresent(empty bool) reflect.Value {
+	*o = Optional[T]{Present: true, Empty: empty}
+	return reflect.ValueOf(&o.Value).Elem()
+}
+
+// The isOptionalType checks if t is Optional of any type
+func isOptionalType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(optionalType)
+}
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// The optionalDecoder returns decoder of Optional values,
+// which decodes the non-empty value with decode.
+// It is given the empty TLV as well.
+func optionalDecoder(decode decodeFunc) decodeFunc {
+	return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+		v := rv.Addr().Interface().(optional).setPresent(len(data) == 0)
+		if len(data) == 0 {
+			return nil, nil
+		}
+		return decode(d, data, v)
+	}
+}
@@ -0,0 +1,87 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestReadRequest struct {
+	Name     Optional[string]         `tlv:"1"`
+	Index    Optional[uint16]         `tlv:"2"`
+	Port     Optional[TestReadPort]   `tlv:"3"`
+	Location Optional[string]         `tlv:"4"`
+	Counters []Optional[TestReadPort] `tlv:"5"`
+}
+
+type TestReadPort struct {
+	Enable Optional[bool] `tlv:"10"`
+}
+
+func TestUnmarshalOptional(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{
+		1, 0, 0,
+		2, 0, 2, 0, 5,
+		3, 0, 3, 10, 0, 0,
+		5, 0, 0,
+		5, 0, 4, 10, 0, 1, 1,
+	}
+	var v TestReadRequest
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(NewEmpty[string](), v.Name)
+	assert.Equal(NewOptional(uint16(5)), v.Index)
+	assert.Equal(NewOptional(TestReadPort{Enable: NewEmpty[bool]()}), v.Port)
+	assert.Equal(Optional[string]{}, v.Location)
+	assert.Equal([]Optional[TestReadPort]{
+		NewEmpty[TestReadPort](),
+		NewOptional(TestReadPort{Enable: NewOptional(true)}),
+	}, v.Counters)
+
+	_, ok := v.Name.Get()
+	assert.False(ok)
+	index, ok := v.Index.Get()
+	assert.True(ok)
+	assert.Equal(uint16(5), index)
+
+	// The Optional is overwritten by repeated TLV, or it is an error in strict mode
+	_, err = Unmarshal(T8L16{2, 0, 2, 0, 5, 2, 0, 0}, &v)
+	assert.NoError(err)
+	assert.Equal(NewEmpty[uint16](), v.Index)
+	_, err = UnmarshalWithOptions(T8L16{2, 0, 2, 0, 5, 2, 0, 0}, &v, DecodeOptions{Strict: true})
+	assert.ErrorIs(err, ErrDuplicateTLV)
+
+	m := map[byte]Optional[uint8]{}
+	_, err = Unmarshal(T8L16{1, 0, 0, 2, 0, 1, 7}, &m)
+	assert.NoError(err)
+	assert.Equal(map[byte]Optional[uint8]{1: NewEmpty[uint8](), 2: NewOptional(uint8(7))}, m)
+}
+
+func TestOptionalAppendT8L16(t *testing.T) {
+	assert := assert.New(t)
+
+	appendUint16 := func(dst []byte, v uint16) []byte {
+		return AppendUint(dst, uint64(v), 2)
+	}
+	b, err := Optional[uint16]{}.AppendT8L16(nil, 2, appendUint16)
+	assert.NoError(err)
+	assert.Empty(b)
+	b, err = NewEmpty[uint16]().AppendT8L16(b, 2, appendUint16)
+	assert.NoError(err)
+	b, err = NewOptional(uint16(5)).AppendT8L16(b, 2, appendUint16)
+	assert.NoError(err)
+	assert.Equal([]byte{2, 0, 0, 2, 0, 2, 0, 5}, b)
+
+	// The encoded TLVs are decoded back to the same values
+	m := map[byte]Optional[uint16]{}
+	_, err = Unmarshal(T8L16(b[:3]), &m)
+	assert.NoError(err)
+	assert.Equal(NewEmpty[uint16](), m[2])
+	var v TestReadRequest
+	_, err = Unmarshal(T8L16(b[3:]), &v)
+	assert.NoError(err)
+	assert.Equal(NewOptional(uint16(5)), v.Index)
+}
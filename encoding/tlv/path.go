@@ -0,0 +1,86 @@
+package tlv
+
+// This file has mapping of TLVs to Go types by full path of TLV types.
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PathMap keeps mapping between full paths of TLV types and Go types.
+// The key is TLV types from top level separated by dots, i.e. "50.19.1"
+// is TLV 1 within TLV 19 within TLV 50.
+//
+// It is given by DecodeOptions, and applies to the values of interface type
+// (or slice of those) at any depth, including the values selected by PathMap,
+// as the Map given to Unmarshal does at top level,
+// so the same TLV type may have different Go types depending on its parents.
+type PathMap map[string]MapEntry
+
+// The index returns PathMap as Map of TLV types within each parent path
+func (pm PathMap) index() (map[string]Map, error) {
+	maps := make(map[string]Map)
+	for key, entry := range pm {
+		parent, last := "", key
+		if i := strings.LastIndexByte(key, '.'); i >= 0 {
+			parent, last = key[:i], key[i+1:]
+		}
+		t, err := strconv.ParseUint(last, 10, 8)
+		if err != nil {
+			return nil, &PathMapKeyError{Key: key}
+		}
+		m := maps[parent]
+		if m == nil {
+			m = make(Map)
+			maps[parent] = m
+		}
+		m[byte(t)] = entry
+	}
+	return maps, nil
+}
+
+// The pathMap returns Map of PathMap for TLVs within path, or nil.
+// The index of PathMap is built once per Unmarshal call.
+func (d *decodeState) pathMap(path []byte) (Map, error) {
+	if d.opts.PathMap == nil {
+		return nil, nil
+	}
+	if d.pathMaps == nil {
+		maps, err := d.opts.PathMap.index()
+		if err != nil {
+			return nil, err
+		}
+		d.pathMaps = maps
+	}
+
+	key := d.key[:0]
+	for i, t := range path {
+		if i > 0 {
+			key = append(key, '.')
+		}
+		key = strconv.AppendUint(key, uint64(t), 10)
+	}
+	d.key = key
+	return d.pathMaps[string(key)], nil
+}
+
+// The interfaceDecoder returns decoder of interface values of field,
+// which uses Map of PathMap for the path of field, or Map m of TLVMapper.
+// The value of others field is whole TLV, so its Map is of parent path.
+func interfaceDecoder(m Map, others bool) decodeFunc {
+	return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+		path := d.path
+		if others {
+			path = path[:len(path)-1]
+		}
+		pm, err := d.pathMap(path)
+		if err != nil {
+			return data, err
+		}
+		if pm != nil {
+			return d.unmarshal(data, rv, pm)
+		}
+		return d.unmarshal(data, rv, m)
+	}
+}
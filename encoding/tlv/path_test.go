@@ -0,0 +1,116 @@
+package tlv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestPathCore struct {
+	Items []interface{} `tlv:"9"`
+}
+
+type TestPathRpd struct {
+	Items []interface{} `tlv:"9"`
+	Info  interface{}   `tlv:"others"`
+}
+
+func TestUnmarshalPathMap(t *testing.T) {
+	assert := assert.New(t)
+
+	pm := PathMap{
+		"50":     {K: "Core", T: reflect.TypeOf(TestPathCore{})},
+		"60":     {K: "Rpd", T: reflect.TypeOf(TestPathRpd{})},
+		"50.9.1": {K: "Name", T: reflect.TypeOf("")},
+		"60.9.1": {K: "Index", T: reflect.TypeOf(uint8(0))},
+		"60.7":   {K: "Mode", T: reflect.TypeOf(uint16(0))},
+	}
+	data := T8L16{
+		50, 0, 7, 9, 0, 4, 1, 0, 1, 'a',
+		60, 0, 12, 9, 0, 4, 1, 0, 1, 5, 7, 0, 2, 0, 3,
+	}
+
+	var v []interface{}
+	rest, err := UnmarshalWithOptions(data, &v, DecodeOptions{PathMap: pm})
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal([]interface{}{
+		TestPathCore{Items: []interface{}{"a"}},
+		TestPathRpd{Items: []interface{}{uint8(5)}, Info: uint16(3)},
+	}, v)
+
+	// The TLV not in PathMap within known parent is located
+	data2 := T8L16{60, 0, 7, 9, 0, 4, 3, 0, 1, 5}
+	v = nil
+	_, err = UnmarshalWithOptions(data2, &v, DecodeOptions{PathMap: pm})
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{60, 9, 3}, de.Path)
+		assert.Equal(6, de.Offset)
+	}
+
+	// The values of unknown parent are kept as is
+	delete(pm, "60.9.1")
+	v = nil
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{PathMap: pm})
+	assert.NoError(err)
+	assert.Equal(TestPathRpd{Items: []interface{}{T8L16{1, 0, 1, 5}}, Info: uint16(3)}, v[1])
+
+	// The nested generic containers have own Map of PathMap
+	pm = PathMap{
+		"1":   {K: "List", T: reflect.TypeOf([]interface{}{})},
+		"1.2": {K: "Index", T: reflect.TypeOf(uint8(0))},
+		"3":   {K: "Any", T: reflect.TypeOf((*interface{})(nil)).Elem()},
+		"3.4": {K: "Name", T: reflect.TypeOf("")},
+	}
+	v = nil
+	_, err = UnmarshalWithOptions(T8L16{1, 0, 4, 2, 0, 1, 5, 3, 0, 4, 4, 0, 1, 'x'}, &v, DecodeOptions{PathMap: pm})
+	assert.NoError(err)
+	assert.Equal([]interface{}{[]interface{}{uint8(5)}, "x"}, v)
+
+	// The keys are dotted numbers
+	var keyErr *PathMapKeyError
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{PathMap: PathMap{"50.x": {}}})
+	if assert.ErrorAs(err, &keyErr) {
+		assert.Equal("50.x", keyErr.Key)
+	}
+}
+
+func TestUnmarshalCheckTags(t *testing.T) {
+	assert := assert.New(t)
+
+	type Core struct {
+		Name string `tlv:"60.5"`
+		Mode uint8  `tlv:"Core.7"`
+	}
+	type Message struct {
+		Core Core `tlv:"60"`
+	}
+
+	data := T8L16{60, 0, 8, 5, 0, 1, 'a', 7, 0, 1, 2}
+	var v Message
+	_, err := UnmarshalWithOptions(data, &v, DecodeOptions{CheckTags: true})
+	assert.NoError(err)
+	assert.Equal(Message{Core{Name: "a", Mode: 2}}, v)
+
+	// The same struct at other path
+	type Other struct {
+		Core Core `tlv:"61"`
+	}
+	data[0] = 61
+	var o Other
+	_, err = Unmarshal(data, &o)
+	assert.NoError(err)
+	_, err = UnmarshalWithOptions(data, &o, DecodeOptions{CheckTags: true})
+	assert.ErrorIs(err, ErrTagMismatch)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{61, 5}, de.Path)
+	}
+
+	// The tag deeper than path
+	_, err = UnmarshalWithOptions(T8L16{5, 0, 0}, &Core{}, DecodeOptions{CheckTags: true})
+	assert.ErrorIs(err, ErrTagMismatch)
+}
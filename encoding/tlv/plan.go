@@ -0,0 +1,306 @@
+package tlv
+
+// This file has compiled decoding plans of struct types.
+// The plan replaces lookups of Map and fields by name for each TLV
+// with direct indexing, and picks the decoder of each field once.
+
+import (
+	"encoding"
+	"fmt"
+	"hash/maphash"
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The structPlan is the decoding plan of struct type.
+type structPlan struct {
+	fields      [256]*fieldPlan // by TLV type
+	unmarshaler bool            // the pointer to struct implements Unmarshaler
+	numFields   int             // number of distinct fields, to find repeated TLVs
+}
+
+// The fieldPlan is the decoding plan of single TLV type to struct field.
+type fieldPlan struct {
+	MapEntry
+	index        []int      // index of field, including embedded structs, nil if struct has no field K
+	id           int        // number of field within plan, to find repeated TLVs
+	ptr          bool       // the field is pointer to be allocated
+	appendStruct bool       // the field is slice of struct, and each TLV appends item
+	repeat       bool       // the field is slice, and each TLV appends to it
+	raw          bool       // the field is RawList, and each TLV appends Raw
+	optional     bool       // the field is Optional, which is given the empty TLV too
+	decode       decodeFunc // the decoder of field value
+}
+
+// The decodeFunc decodes data to rv, and returns unprocessed data.
+type decodeFunc func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error)
+
+// The getPlan returns cached plan of struct type t.
+// The plan is build out of struct tags, and cached in cachePlan.
+func getPlan(t reflect.Type) (*structPlan, error) {
+	if p, ok := cachePlan.Load(t); ok {
+		return p.(*structPlan), nil
+	}
+
+	m, err := getTlvMap(t)
+	if err != nil {
+		return nil, err
+	}
+	p := newPlan(t, m)
+
+	cachePlan.Store(t, p)
+	return p, nil
+}
+
+var cachePlan sync.Map // map[reflect.Type]*structPlan
+
+// The getHintPlan returns cached plan of struct type t with Map m given to Unmarshal.
+// The Map is usually literal of each call, so the plan is cached by content of Map,
+// and the copy of cached Map tells apart the Maps of the same hash.
+// The cache is dropped once it has maxHintPlans plans,
+// so the Maps built at run time do not grow it without bound.
+func getHintPlan(t reflect.Type, m Map) *structPlan {
+	key := hintKey{t: t, hash: hashMap(m)}
+	if hp, ok := cacheHintPlan.Load(key); ok && sameMap(hp.(*hintPlan).m, m) {
+		return hp.(*hintPlan).p
+	}
+
+	m = maps.Clone(m)
+	p := newPlan(t, m)
+	if numHintPlans.Add(1) > maxHintPlans {
+		clearHintPlans()
+		numHintPlans.Add(1)
+	}
+	cacheHintPlan.Store(key, &hintPlan{m: m, p: p})
+	return p
+}
+
+var (
+	cacheHintPlan sync.Map     // map[hintKey]*hintPlan
+	numHintPlans  atomic.Int64 // number of plans stored to cacheHintPlan
+)
+
+// The maxHintPlans bounds the number of cached plans of Maps given to Unmarshal
+const maxHintPlans = 256
+
+// The clearHintPlans drops all cached plans of Maps given to Unmarshal
+func clearHintPlans() {
+	cacheHintPlan.Clear()
+	numHintPlans.Store(0)
+}
+
+type hintKey struct {
+	t    reflect.Type
+	hash uint64
+}
+
+type hintPlan struct {
+	m Map // the copy of Map the plan is built of
+	p *structPlan
+}
+
+var hintSeed = maphash.MakeSeed()
+
+// The hashMap returns hash of TLV types and fields of m, which does not depend on order
+func hashMap(m Map) uint64 {
+	var sum uint64
+	for n, entry := range m {
+		var h maphash.Hash
+		h.SetSeed(hintSeed)
+		h.WriteByte(n)
+		h.WriteString(entry.K)
+		maphash.WriteComparable(&h, entry.T)
+		sum += h.Sum64()
+	}
+	return sum
+}
+
+// The sameMap checks if Maps given to Unmarshal have the same TLV types and fields
+func sameMap(a, b Map) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n, ea := range a {
+		eb, ok := b[n]
+		if !ok || ea.K != eb.K || ea.T != eb.T {
+			return false
+		}
+	}
+	return true
+}
+
+// The newPlan compiles plan of struct type t for map m.
+func newPlan(t reflect.Type, m Map) *structPlan {
+	p := &structPlan{
+		unmarshaler: reflect.PtrTo(t).Implements(unmarshalerType),
+	}
+	mapper := getMapper(t)
+
+	ids := map[string]int{}
+	for n, entry := range m {
+		fp := &fieldPlan{MapEntry: entry}
+		p.fields[n] = fp
+
+		// The Map given to Unmarshal has field names only
+		var sf reflect.StructField
+		if entry.index != nil {
+			sf = t.FieldByIndex(entry.index)
+			fp.index = entry.index
+		} else if f, ok := t.FieldByName(entry.K); ok {
+			sf = f
+			fp.index = f.Index
+		} else {
+			continue
+		}
+
+		// The many TLV types may be mapped to the same field
+		key := fmt.Sprint(fp.index)
+		id, ok := ids[key]
+		if !ok {
+			id = len(ids)
+			ids[key] = id
+		}
+		fp.id = id
+		if entry.bits != nil {
+			continue
+		}
+
+		ft := sf.Type
+		if ft == rawListType {
+			fp.raw = true
+			fp.repeat = true
+			continue
+		}
+		if ft.Kind() == reflect.Ptr {
+			fp.ptr = true
+			ft = ft.Elem()
+		}
+		fp.repeat = (ft.Kind() == reflect.Slice && ft.Elem() != byteType || ft.Kind() == reflect.Map) && !hasCustomDecoder(ft)
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct {
+			fp.appendStruct = true
+			ft = ft.Elem()
+		}
+		fp.optional = isOptionalType(ft)
+		fp.decode = compileDecoder(ft)
+		if entry.vendor != 0 {
+			fp.decode = vendorDecoder(entry.vendor)
+		}
+		if entry.keyed {
+			fp.decode = keyedMapDecoder(entry.key)
+		}
+		if isInterfaceType(ft) && !hasCustomDecoder(ft) {
+			var fm Map
+			if mapper != nil {
+				fm = mapper.TLVMap(entry.K)
+			}
+			fp.decode = interfaceDecoder(fm, n == AllOthers && !entry.zero)
+		}
+	}
+
+	p.numFields = len(ids)
+	return p
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// The compileDecoder returns the decoder of values of type t.
+// The common basic types and the structs are decoded directly,
+// and any other type by generic unmarshal.
+func compileDecoder(t reflect.Type) decodeFunc {
+	if hasCustomDecoder(t) {
+		return unmarshalGeneric
+	}
+
+	switch {
+	case t == timeType:
+		return unmarshalGeneric
+
+	case isOptionalType(t):
+		vt, _ := t.FieldByName("Value")
+		return optionalDecoder(compileDecoder(vt.Type))
+
+	case basicSize(t.Kind()) != 0:
+		if t.Implements(enumType) {
+			return unmarshalGeneric
+		}
+		return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+			return d.unmarshalBasicType(data, rv)
+		}
+
+	case t.Kind() == reflect.String:
+		return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+			if err := d.budget.StringLen(len(data)); err != nil {
+				return data, err
+			}
+			rv.SetString(string(data))
+			return nil, nil
+		}
+
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+			rv.SetBytes(data[:len(data):len(data)])
+			return nil, nil
+		}
+
+	case t.Kind() == reflect.Struct:
+		if bf, err := getBitFields(t); err != nil || bf != nil {
+			return unmarshalGeneric
+		}
+		return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+			p, err := getPlan(t)
+			if err != nil {
+				return data, err
+			}
+			return d.unmarshalPlan(data, rv, p)
+		}
+	}
+
+	return unmarshalGeneric
+}
+
+// The getMapper returns TLVMapper of struct type t, or nil
+func getMapper(t reflect.Type) TLVMapper {
+	switch {
+	case t.Implements(mapperType):
+		return reflect.Zero(t).Interface().(TLVMapper)
+	case reflect.PtrTo(t).Implements(mapperType):
+		return reflect.New(t).Interface().(TLVMapper)
+	}
+	return nil
+}
+
+// The isInterfaceType checks if t is interface or slice of interfaces
+func isInterfaceType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Interface
+}
+
+func unmarshalGeneric(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+	return d.unmarshal(data, rv, nil)
+}
+
+// The hasCustomDecoder checks if values of type t are decoded by unmarshalCustom.
+func hasCustomDecoder(t reflect.Type) bool {
+	if _, ok := decoders.Load(t); ok {
+		return true
+	}
+	pt := reflect.PtrTo(t)
+	return pt.Implements(budgetUnmarshalerType) || pt.Implements(binaryUnmarshalerType) ||
+		t.Kind() == reflect.String && pt.Implements(textUnmarshalerType)
+}
+
+var (
+	timeType              = reflect.TypeOf(time.Time{})
+	byteType              = reflect.TypeOf(byte(0))
+	rawListType           = reflect.TypeOf(RawList{})
+	enumType              = reflect.TypeOf((*TLVEnum)(nil)).Elem()
+	mapperType            = reflect.TypeOf((*TLVMapper)(nil)).Elem()
+	budgetUnmarshalerType = reflect.TypeOf((*BudgetUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
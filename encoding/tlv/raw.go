@@ -0,0 +1,42 @@
+package tlv
+
+// Raw is the undecoded TLV kept with its position,
+// so it could be encoded back in place (i.e. forwarded vendor extensions).
+type Raw struct {
+	Index int   // index of TLV among TLVs of parent, or of top level
+	TLV   T8L16 // the whole TLV
+}
+
+// RawList is the list of undecoded TLVs in order of Index.
+//
+// The struct field of type RawList tagged `tlv:"others"`, or without `tlv` tag
+// (by convention `Unknown tlv.RawList`), is given by Unmarshal the TLVs
+// without own entry of Map, as those would be given to the AllOthers entry.
+type RawList []Raw
+
+// Merge returns TLVs of known values with the raw TLVs of l
+// inserted back at their original index.
+// The known TLVs shall be in the original order, so the marshaling
+// of modified value preserves the order of TLVs as it was decoded.
+// The raw TLVs with index beyond known TLVs are appended at the end.
+func (l RawList) Merge(known ...T8L16) T8L16 {
+	size := 0
+	for _, r := range l {
+		size += len(r.TLV)
+	}
+	for _, k := range known {
+		size += len(k)
+	}
+
+	out := make(T8L16, 0, size)
+	for index := 0; len(l) > 0 || len(known) > 0; index++ {
+		if len(l) > 0 && (l[0].Index <= index || len(known) == 0) {
+			out = append(out, l[0].TLV...)
+			l = l[1:]
+			continue
+		}
+		out = append(out, known[0]...)
+		known = known[1:]
+	}
+	return out
+}
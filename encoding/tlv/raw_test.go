@@ -0,0 +1,117 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestRawCore struct {
+	Index   uint8 `tlv:"1"`
+	Unknown RawList
+}
+
+type TestRawMessage struct {
+	Version uint8         `tlv:"1"`
+	Cores   []TestRawCore `tlv:"9"`
+	Unknown RawList       `tlv:"others"`
+}
+
+func TestUnmarshalRaw(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{
+		200, 0, 1, 0xAA,
+		1, 0, 1, 1,
+		9, 0, 11, 7, 0, 0, 1, 0, 1, 5, 8, 0, 1, 8,
+		201, 0, 0,
+	}
+	var v TestRawMessage
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(uint8(1), v.Version)
+	assert.Equal(RawList{
+		{Index: 0, TLV: T8L16{200, 0, 1, 0xAA}},
+		{Index: 3, TLV: T8L16{201, 0, 0}},
+	}, v.Unknown)
+	if assert.Len(v.Cores, 1) {
+		assert.Equal(uint8(5), v.Cores[0].Index)
+		assert.Equal(RawList{
+			{Index: 0, TLV: T8L16{7, 0, 0}},
+			{Index: 2, TLV: T8L16{8, 0, 1, 8}},
+		}, v.Cores[0].Unknown)
+	}
+
+	// The raw TLVs have no capacity beyond own length
+	raw := v.Unknown[0].TLV
+	assert.Equal(len(raw), cap(raw))
+
+	// The raw TLVs are repeatable in strict mode,
+	// and are reported as unknown as well
+	var unknown []UnknownTLV
+	v = TestRawMessage{}
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{Strict: true, Unknown: &unknown})
+	assert.NoError(err)
+	assert.Len(unknown, 4)
+
+	// The raw TLVs are within limits
+	_, err = UnmarshalWithOptions(data, &v, DecodeOptions{Limits: Limits{MaxSliceLen: 1}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+}
+
+func TestUnmarshalZeroTag(t *testing.T) {
+	assert := assert.New(t)
+
+	// The TLV 0 is value of field tagged "0", not one of others
+	var v struct {
+		Zero    uint8   `tlv:"0"`
+		Version uint8   `tlv:"1"`
+		Unknown RawList `tlv:"others"`
+	}
+	_, err := Unmarshal(T8L16{0, 0, 1, 7, 1, 0, 1, 1}, &v)
+	assert.ErrorAs(err, new(*DuplicateTLVError))
+
+	var z struct {
+		Zero    uint8 `tlv:"0"`
+		Version uint8 `tlv:"1"`
+	}
+	_, err = Unmarshal(T8L16{0, 0, 1, 7, 1, 0, 1, 1}, &z)
+	assert.NoError(err)
+	assert.Equal(uint8(7), z.Zero)
+	assert.Equal(uint8(1), z.Version)
+
+	// The unknown TLV is not given to it
+	_, err = Unmarshal(T8L16{5, 0, 1, 7}, &z)
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+
+	// The TLV 0 is one of others without such field
+	var o TestRawMessage
+	_, err = Unmarshal(T8L16{0, 0, 1, 7}, &o)
+	assert.NoError(err)
+	assert.Equal(RawList{{Index: 0, TLV: T8L16{0, 0, 1, 7}}}, o.Unknown)
+}
+
+func TestRawListMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	data := T8L16{
+		200, 0, 1, 0xAA,
+		1, 0, 1, 1,
+		9, 0, 11, 7, 0, 0, 1, 0, 1, 5, 8, 0, 1, 8,
+		201, 0, 0,
+	}
+	var v TestRawMessage
+	_, err := Unmarshal(data, &v)
+	assert.NoError(err)
+
+	// The known TLVs are encoded in original order
+	core := v.Cores[0].Unknown.Merge(T8L16{1, 0, 1, 5})
+	known := []T8L16{{1, 0, 1, 1}, append(T8L16{9, 0, byte(len(core))}, core...)}
+	assert.Equal(data, v.Unknown.Merge(known...))
+
+	// The raw TLVs beyond known ones are appended
+	assert.Equal(T8L16{200, 0, 1, 0xAA, 201, 0, 0}, v.Unknown.Merge())
+	assert.Equal(T8L16{1, 0, 0}, RawList(nil).Merge(T8L16{1, 0, 0}))
+	assert.Empty(RawList(nil).Merge())
+}
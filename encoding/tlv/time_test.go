@@ -66,3 +66,41 @@ func TestTime11(t *testing.T) {
 	_, z := v.Zone()
 	assert.Equal(z, -(3*3600 + 30*60))
 }
+
+func TestAppendTime(t *testing.T) {
+	assert := assert.New(t)
+
+	v := time.Date(2025, 11, 29, 8, 30, 22, 712_000_000, time.UTC)
+	assert.Equal([]byte{0x07, 0xE9, 11, 29, 8, 30, 22, 7}, AppendTime(nil, v))
+
+	v = time.Date(2025, 11, 29, 8, 30, 22, 700_000_000, time.FixedZone("", -(3*3600+30*60)))
+	b := AppendTime(nil, v)
+	assert.Equal([]byte{0x07, 0xE9, 11, 29, 8, 30, 22, 7, byte('-'), 3, 30}, b)
+
+	var u time.Time
+	_, err := Unmarshal(b, &u)
+	assert.NoError(err)
+	assert.True(v.Equal(u))
+}
+
+func TestTimeBad(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, data := range []T8L16{
+		{0x07, 0xE9, 2, 29, 8, 30, 22, 7},  // not a leap year
+		{0x07, 0xE8, 4, 31, 8, 30, 22, 7},  // April has 30 days
+		{0x00, 0x00, 11, 29, 8, 30, 22, 7}, // year 0
+		{0x27, 0x10, 11, 29, 8, 30, 22, 7}, // year 10000
+		{0x07, 0xE9, 13, 29, 8, 30, 22, 7},
+		{0x07, 0xE9, 11, 29, 24, 30, 22, 7},
+	} {
+		var v time.Time
+		_, err := Unmarshal(data, &v)
+		assert.ErrorIs(err, ErrBadTime, "%v", data)
+	}
+
+	var v time.Time
+	_, err := Unmarshal(T8L16{0x07, 0xE8, 2, 29, 8, 30, 22, 7}, &v)
+	assert.NoError(err)
+	assert.Equal(29, v.Day())
+}
@@ -3,12 +3,12 @@ package tlv
 import (
 	"io"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/cloudcopper/core/encoding/binary"
-	"github.com/pkg/errors"
 )
 
 // T8L16 is the type for TLV (Type-Length-Value) raw data
@@ -46,6 +46,17 @@ type Unmarshaler interface {
 	EmptyTLVType(byte, string)
 }
 
+// TLVMapper is the interface implemented by the struct types,
+// which have fields of interface type (or slice of those) decoded to concrete Go types.
+// TLVMap returns the Map of TLV types of value of field, or nil.
+// The value of such field is TLV, which type selects the Go type,
+// as Unmarshal does for interface with Map given.
+// The TLVMap is called once per decoding plan of struct type (see Unmarshal),
+// so it shall not depend on the struct value.
+type TLVMapper interface {
+	TLVMap(field string) Map
+}
+
 // Map type keeps mapping between TLV types and Go types.
 // The key is the TLV Type.
 type Map map[byte]MapEntry
@@ -59,13 +70,22 @@ type Map map[byte]MapEntry
 type MapEntry struct {
 	K string
 	T reflect.Type
+
+	bits   []bitField // the bit-fields sharing TLV type
+	index  []int      // index of field K, including embedded structs
+	vendor byte       // TLV type of vendor ID of VendorSpecific, 0 if not
+	path   []int      // TLV types of dotted struct tag, -1 if not a number
+	key    byte       // TLV type of key of map items, if keyed
+	keyed  bool       // the map field has option "key=N"
+	zero   bool       // the field is tagged "0", so TLV 0 is its value, not one of all others
 }
 
 // AllOthers is the special Map key used by Unmarshal to catch all others TLV types.
 const AllOthers = 0
 
 // The getTlvMap returns cached Map of TLV Types to Go struct fields.
-// The map is build out of struct using structr tags.
+// The map is build out of struct using structr tags,
+// including fields of embedded structs (see addTlvFields).
 // The map is cached in cacheTlvMap.
 func getTlvMap(t reflect.Type) (Map, error) {
 	// Try to get the map out of cache
@@ -75,46 +95,238 @@ func getTlvMap(t reflect.Type) (Map, error) {
 
 	// Validate the requested type. Shall be struct
 	if t.Kind() != reflect.Struct {
-		return nil, &WrongKindError{t.Kind(), nil}
+		return nil, &WrongKindError{Kind: t.Kind()}
 	}
 
-	// Create map out of all fields of requested struct type
+	// Create map out of all fields of requested struct type,
+	// including the fields of embedded structs
 	m := make(Map)
+	owners := map[byte]*tlvOwner{}
+	if err := addTlvFields(m, owners, t, nil, nil); err != nil {
+		return nil, err
+	}
+	for n := 0; n < 256; n++ {
+		if o, ok := owners[byte(n)]; ok && o.other != "" {
+			return nil, &DuplicateTLVError{TLV: byte(n), Fields: [2]string{m[byte(n)].K, o.other}}
+		}
+	}
+
+	// The RawList field without tag collects the TLVs without own entry,
+	// unless there is the field tagged "others"
+	if _, ok := m[AllOthers]; !ok {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if _, ok := sf.Tag.Lookup("tlv"); !ok && sf.Type == rawListType {
+				m[AllOthers] = MapEntry{K: sf.Name, T: sf.Type}
+				break
+			}
+		}
+	}
+
+	cacheTlvMap.Store(t, m)
+	return m, nil
+}
+
+var cacheTlvMap sync.Map // map[reflect.Value]TlvMap
+
+// The addTlvFields adds to m the fields of struct t with `tlv` tags,
+// and the fields of embedded structs, as those are promoted.
+// The index is index of t within the struct of m, and parents are
+// the types t is embedded into, to stop on recursive embedding.
+// The owners are structs of TLV types added already (see claim).
+func addTlvFields(m Map, owners map[byte]*tlvOwner, t reflect.Type, index []int, parents []reflect.Type) error {
 	for i := 0; i < t.NumField(); i++ {
 		sf := t.Field(i)
+		sf.Index = append(append([]int(nil), index...), i)
 
 		// Parse tag "tlv"
 		// It must have value "others" or type in canonical form - i.e. "50.19.1"
-		// Only last digit is used.
+		// Only last digit is used, but all are checked with DecodeOptions.CheckTags.
 		tag, ok := sf.Tag.Lookup("tlv")
 		if !ok {
+			if et := embeddedStruct(sf, append(parents, t)); et != nil {
+				if err := addTlvFields(m, owners, et, sf.Index, append(parents, t)); err != nil {
+					return err
+				}
+			}
 			continue
 		}
 		if tag == "" {
-			return nil, ErrEmptyStructTag
+			return ErrEmptyStructTag
+		}
+		tag, opts, _ := strings.Cut(tag, ",")
+		key, keyed, err := parseTagOptions(sf, opts)
+		if err != nil {
+			return err
 		}
 
 		var n byte
+		var path []int
+		var zero bool
 		if tag == "others" {
 			n = AllOthers
 		} else {
 			as := strings.Split(tag, ".")
+			path = parseTagPath(as)
 			s := as[len(as)-1]
 			base := 0
 			bitSize := 8
 			i, err := strconv.ParseInt(s, base, bitSize)
 			if err != nil {
-				return nil, errors.WithStack(err)
+				return err
 			}
 			n = byte(i)
+			zero = n == 0
+		}
+
+		if tag, ok := sf.Tag.Lookup("bits"); ok {
+			f, err := parseBitsTag(sf, tag)
+			if err != nil {
+				return err
+			}
+			if !claim(m, owners, n, sf, index) {
+				continue
+			}
+			entry, ok := m[n]
+			if ok && entry.bits == nil {
+				return ErrBadBitsTag
+			}
+			if !ok {
+				entry = MapEntry{K: sf.Name, T: sf.Type, index: sf.Index, path: path, zero: zero}
+			}
+			entry.bits = append(entry.bits, f)
+			m[n] = entry
+			continue
 		}
 
-		entry := MapEntry{sf.Name, sf.Type}
+		if !claim(m, owners, n, sf, index) {
+			continue
+		}
+		// The bit-fields are not overridden by other field of the same struct,
+		// and the fields tagged "0" and "others" would share the entry
+		if e, ok := m[n]; ok && e.bits != nil {
+			return ErrBadBitsTag
+		} else if ok && n == AllOthers && e.zero != zero {
+			return &DuplicateTLVError{TLV: n, Fields: [2]string{e.K, sf.Name}}
+		}
+		entry := MapEntry{K: sf.Name, T: sf.Type, index: sf.Index, path: path, key: key, keyed: keyed, zero: zero}
+		if tag, ok := sf.Tag.Lookup("vendor"); ok {
+			vendor, err := parseVendorTag(sf, tag)
+			if err != nil {
+				return err
+			}
+			entry.vendor = vendor
+		}
 		m[n] = entry
 	}
+	return nil
+}
 
-	cacheTlvMap.Store(t, m)
-	return m, nil
+// The embeddedStruct returns type of struct embedded by field sf (by value or pointer),
+// or nil if the field is not such. As of encoding/json, the exported fields
+// of unexported embedded struct are promoted, but the unexported pointer
+// is not settable to be allocated. The recursive embedding is ignored.
+func embeddedStruct(sf reflect.StructField, parents []reflect.Type) reflect.Type {
+	if !sf.Anonymous {
+		return nil
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		if !sf.IsExported() {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	for _, p := range parents {
+		if p == t {
+			return nil
+		}
+	}
+	return t
 }
 
-var cacheTlvMap sync.Map // map[reflect.Value]TlvMap
+// The tlvOwner is the struct of field of TLV type
+type tlvOwner struct {
+	index []int  // the index of struct, nil for the top level
+	other string // the field of another struct of the same depth, if any
+}
+
+// The claim checks if field sf of struct index takes TLV type n, as encoding/json does:
+// the field of shallower struct wins (its entry replaces the one of m),
+// and the fields of the same struct share TLV type as bit-fields,
+// or the later field overrides the earlier one.
+// The fields of different structs of the same depth are ambiguous,
+// unless the field of shallower struct takes TLV type.
+func claim(m Map, owners map[byte]*tlvOwner, n byte, sf reflect.StructField, index []int) bool {
+	o, ok := owners[n]
+	switch {
+	case !ok || len(index) < len(o.index):
+		delete(m, n)
+		owners[n] = &tlvOwner{index: index}
+		return true
+	case slices.Equal(o.index, index):
+		return true
+	case len(index) == len(o.index) && o.other == "":
+		o.other = sf.Name
+	}
+	return false
+}
+
+// The parseTagPath returns TLV types of dotted struct tag split to octets,
+// or nil if there is single octet. The octets not being numbers are -1.
+func parseTagPath(octets []string) []int {
+	if len(octets) < 2 {
+		return nil
+	}
+	path := make([]int, len(octets))
+	for i, s := range octets {
+		t, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			path[i] = -1
+			continue
+		}
+		path[i] = int(t)
+	}
+	return path
+}
+
+// The matchTagPath checks if tag path matches the end of TLV path
+func matchTagPath(tag []int, path []byte) bool {
+	if len(tag) > len(path) {
+		return false
+	}
+	path = path[len(path)-len(tag):]
+	for i, t := range tag {
+		if t >= 0 && t != int(path[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// The parseTagOptions parses options of `tlv` struct tag after comma,
+// which is "key=N" of map field.
+func parseTagOptions(sf reflect.StructField, opts string) (byte, bool, error) {
+	if opts == "" {
+		return 0, false, nil
+	}
+	name, value, _ := strings.Cut(opts, "=")
+	if name != "key" {
+		return 0, false, ErrBadTagOption
+	}
+	key, err := strconv.ParseUint(value, 0, 8)
+	if err != nil {
+		return 0, false, ErrBadTagOption
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Map {
+		return 0, false, ErrBadTagOption
+	}
+	return byte(key), true, nil
+}
@@ -1,28 +1,85 @@
 package tlv
 
 import (
+	"errors"
+	"math"
 	"reflect"
+	"sync"
 	"time"
-
-	"github.com/cloudcopper/core/encoding/binary"
-	"github.com/pkg/errors"
 )
 
 // Unmarshal decode TLV data and stores the result in the Go value pointed by v.
 // If v is not pointer to supported types, Unmarshal returns an error.
 // Optional 3rd arg is map for TLV Types to Go types.
+// The decoding plan of struct type with the map is cached by content of map,
+// as the plan of struct type with tags is.
 //
 // Function returns unprocessed data and error.
+// The error is *DecodeError, which locates failed TLV by offset and path,
+// and wraps the cause (i.e. ErrNotEnoughData) for errors.Is and errors.As.
+//
+// Supported input types: *struct, *[]struct, *interface{}, *[]interface{}, *T, *[]T, *map[byte]T
+//
+// The map[byte]T is keyed by TLV type, and the map field with tag option
+// `tlv:"16,key=12"` is keyed by value of TLV 12 of each item TLV 16.
 //
-// Supported input types: *struct, *[]struct, *interface{}, *[]interface{}, *T, *[]T
+// The tagged fields of embedded structs (or pointers to structs) without
+// `tlv` tag are mapped as fields of the outer struct, and the pointers are
+// allocated as needed. As of encoding/json, the field of shallower struct wins,
+// and the same TLV type of fields of different structs of the same depth
+// is *DuplicateTLVError.
 //
 // In some cases you want to know more on unmarshaled data - i.e.
 // order of elements, which elements had zero sized value, and TLV type
 // for which the struct was allocated (i.e. many to one map relations).
 // Then the structure should implemented interface Unmarshaler.
 //
+// The bitmask TLV value (up to 8 octets) may be mapped to bool or integer
+// fields with struct tag `bits:"N"` or `bits:"N-M"`, where bit 0 is
+// the least significant bit and the range is inclusive.
+// Either the fields are of own struct type, which has no `tlv` tags,
+// or the fields share the same tag `tlv:"N"` within the parent struct.
+//
+// The Go types with decoder registered by RegisterDecoder, or implementing
+// BudgetUnmarshaler or encoding.BinaryUnmarshaler, are given the whole TLV value.
+// The same applies to encoding.TextUnmarshaler of string types only,
+// so the text form of other types (i.e. net.IP or config structs) is not used.
+// The time.Time is always decoded as TLV DateAndTime.
+//
+// The basic types are decoded in network byte order as following:
+//   - bool, int8 and uint8 take 1 octet, int16 and uint16 take 2 octets,
+//     int32, uint32 and float32 take 4 octets, int64, uint64 and float64 take 8 octets;
+//   - platform sized int and uint take 8 octets, as int64 and uint64;
+//   - float32 and float64 are IEEE-754, and shorter data is an error;
+//   - shorter data of unsigned integers is zero extended,
+//     and shorter data of signed integers is sign extended,
+//     so single octet 0xFF decodes to int16 as -1;
+//   - longer data is left unprocessed, or is an error in strict mode.
+//
+// The strict mode (see DecodeOptions) also makes an error the shorter data
+// of fixed size values, the data of byte arrays not matching the array length,
+// and the repeated TLV of field, which is not a slice (i.e. it would be overwritten).
+// The empty TLV is not an error, as it is used for requests without value.
+//
+// The field of type Optional tells the absent TLV, the empty TLV
+// (i.e. RCP read request) and the TLV with value apart, without pointers.
+//
+// The TLV types without own entry of Map are an error, or are given
+// to the AllOthers entry if any. Both may be reported by DecodeOptions.Unknown.
+// The field of type RawList keeps those with own positions (see Raw).
+// The field of type VendorSpecific is decoded to Go type of vendor (see RegisterVendor).
+//
+// The Map of fields of interface type (or slice of those) is given by struct
+// implementing TLVMapper, so the nested values are decoded to concrete types at any depth.
+// The DecodeOptions.PathMap does the same by full path of TLV types.
+//
 // Please see examples.
 func Unmarshal(data T8L16, v interface{}, hint ...Map) ([]byte, error) {
+	return UnmarshalWithOptions(data, v, DecodeOptions{}, hint...)
+}
+
+// UnmarshalWithOptions is the Unmarshal controlled by opts.
+func UnmarshalWithOptions(data T8L16, v interface{}, opts DecodeOptions, hint ...Map) ([]byte, error) {
 	rv := reflect.Indirect(reflect.ValueOf(v))
 
 	var m Map
@@ -30,13 +87,168 @@ func Unmarshal(data T8L16, v interface{}, hint ...Map) ([]byte, error) {
 		m = hint[0]
 	}
 
-	var path []byte
-	return unmarshal(data, rv, m, path)
+	// The decode state is reused, so the decoding does not allocate it
+	d := decodeStates.Get().(*decodeState)
+	*d = decodeState{opts: opts, data: data, key: d.key[:0]}
+	d.path = d.buf[:0]
+	d.names = d.nbuf[:0]
+	defer d.release()
+	if m == nil && rv.IsValid() && isInterfaceType(rv.Type()) {
+		pm, err := d.pathMap(nil)
+		if err != nil {
+			return data, d.fail(data, err)
+		}
+		m = pm
+	}
+	if opts.Limits != (Limits{}) {
+		d.budget = NewBudget(opts.Limits)
+	}
+	if err := d.budget.Bytes(len(data)); err != nil {
+		return data, d.fail(data, err)
+	}
+	rest, err := d.unmarshal(data, rv, m)
+	if err != nil {
+		return rest, d.fail(data, err)
+	}
+	return rest, nil
+}
+
+// DecodeOptions controls the unmarshaling.
+// The zero value is the default behaviour of Unmarshal.
+type DecodeOptions struct {
+	// Strict makes data longer than target basic type an error,
+	// instead of leaving it unprocessed.
+	// It also makes undefined values of TLVEnum an error,
+	// as well as the shorter data of fixed size values,
+	// and the repeated TLVs of non-slice fields.
+	Strict bool
+
+	// Unknown collects the TLVs without own entry of Map, if not nil.
+	// Such TLVs are skipped instead of failing with ErrTlvMapHasNoEntry,
+	// or are decoded to the AllOthers entry as usual.
+	Unknown *[]UnknownTLV
+
+	// Limits bounds the decoding of untrusted data.
+	Limits Limits
+
+	// PathMap maps TLVs to Go types of interface values by full path.
+	// It takes precedence over Map given by TLVMapper.
+	PathMap PathMap
+
+	// CheckTags makes an error the dotted struct tag, i.e. `tlv:"50.19.1"`,
+	// which does not match the types of TLV and its parents.
+	// The octets, which are not numbers, match any type.
+	CheckTags bool
+}
+
+// The decodeState keeps state of single Unmarshal call.
+type decodeState struct {
+	opts   DecodeOptions
+	data   T8L16    // the whole input, to locate errors
+	path   []byte   // TLV types of current value, reused to avoid allocations
+	names  []string // names of fields along path, reused as path
+	buf    [8]byte
+	nbuf   [8]string
+	budget *Budget // nil without limits
+
+	pathMaps map[string]Map // index of PathMap, built on first use
+	key      []byte         // key of PathMap, reused to avoid allocations
+}
+
+var decodeStates = sync.Pool{New: func() interface{} { return new(decodeState) }}
+
+// The release returns d to decodeStates without references to decoded data
+func (d *decodeState) release() {
+	*d = decodeState{key: d.key[:0]}
+	decodeStates.Put(d)
+}
+
+// The push enters TLV t of field name.
+// It fails, if the TLV exceeds the limits,
+// but the TLV is on the path anyway to locate the error.
+func (d *decodeState) push(t byte, name string) error {
+	d.path = append(d.path, t)
+	d.names = append(d.names, name)
+	return d.budget.Enter()
+}
+
+func (d *decodeState) pop() {
+	d.path = d.path[:len(d.path)-1]
+	d.names = d.names[:len(d.names)-1]
+	d.budget.Leave()
+}
+
+// The appendValue checks the limits of appending item to slice rv
+func (d *decodeState) appendValue(rv reflect.Value) error {
+	if err := d.budget.SliceLen(rv.Len() + 1); err != nil {
+		return err
+	}
+	return d.budget.Value()
+}
+
+// UnknownTLV is the TLV without own entry of Map,
+// which was collected by DecodeOptions.Unknown.
+type UnknownTLV struct {
+	Offset int      // offset of TLV in data
+	Path   []int    // TLV types from top level down to the TLV
+	Names  []string // names of TLVs along Path, if known
+	TLV    T8L16    // the whole TLV
+}
+
+// The unknown reports TLV tlv of type t to DecodeOptions.Unknown.
+// It returns false, if the unknown TLVs are not collected.
+func (d *decodeState) unknown(t byte, tlv T8L16) bool {
+	if d.opts.Unknown == nil {
+		return false
+	}
+	d.path = append(d.path, t)
+	d.names = append(d.names, "")
+	path, names := d.location()
+	d.path = d.path[:len(d.path)-1]
+	d.names = d.names[:len(d.names)-1]
+	*d.opts.Unknown = append(*d.opts.Unknown, UnknownTLV{
+		Offset: cap(d.data) - cap(tlv),
+		Path:   path,
+		Names:  names,
+		TLV:    tlv[:len(tlv):len(tlv)],
+	})
+	return true
+}
+
+// The location returns copy of current path and names
+func (d *decodeState) location() ([]int, []string) {
+	if len(d.path) == 0 {
+		return nil, nil
+	}
+	path := make([]int, len(d.path))
+	for i, t := range d.path {
+		path[i] = int(t)
+	}
+	return path, append([]string(nil), d.names...)
 }
 
-// unmarshal process data to rv according to m until first error.
+// The fail returns err as DecodeError at current path and data.
+// The path is copied only here, so it is allocated only in case of error.
+// The err being DecodeError already is returned as is,
+// as it was located by the deeper value.
+func (d *decodeState) fail(data T8L16, err error) error {
+	var de *DecodeError
+	if errors.As(err, &de) {
+		return err
+	}
+
+	de = &DecodeError{Offset: -1, Err: err}
+	de.Path, de.Names = d.location()
+	// The data is subslice of input, so offset is difference of capacities
+	if d.data != nil && cap(data) <= cap(d.data) {
+		de.Offset = cap(d.data) - cap(data)
+	}
+	return de
+}
+
+// The unmarshal process data to rv according to m until first error.
 // The rv must not be a pointer. It must be dereferenced already.
-func unmarshal(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshal(data T8L16, rv reflect.Value, m Map) ([]byte, error) {
 	// Check the preconditions
 	if !rv.IsValid() {
 		return data, ErrReflectValueIsInvalid
@@ -48,41 +260,68 @@ func unmarshal(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error)
 		return data, ErrReflectValueIsNotSettable
 	}
 
+	if isOptionalType(rv.Type()) {
+		return optionalDecoder(func(d *decodeState, data T8L16, v reflect.Value) ([]byte, error) {
+			return d.unmarshal(data, v, m)
+		})(d, data, rv)
+	}
+
+	if m == nil {
+		if ok, rest, err := unmarshalCustom(data, rv, d.budget); ok {
+			return rest, err
+		}
+	}
+
 	if rv.Kind() == reflect.Slice {
-		return unmarshalSlice(data, rv, m, path)
+		return d.unmarshalSlice(data, rv, m)
+	}
+	if rv.Kind() == reflect.Map {
+		return d.unmarshalMap(data, rv)
 	}
 
-	return unmarshalValue(data, rv, m, path)
+	return d.unmarshalValue(data, rv, m)
 }
 
-func unmarshalSlice(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshalSlice(data T8L16, rv reflect.Value, m Map) ([]byte, error) {
 	t := rv.Type().Elem()
 	if t.Kind() == reflect.Struct || t.Kind() == reflect.Interface {
-		return unmarshalComplexSlice(data, rv, m, path)
+		return d.unmarshalComplexSlice(data, rv, m)
 	}
 
 	if isByteSlice(rv) {
 		// This is special case where rv is []byte
 		// and there is no needs to process byte by byte
-		// but whole slice could be just copied
-		rv.SetBytes(data)
+		// but whole slice could be just copied.
+		// The slice has no capacity beyond data,
+		// so appending to it never overwrites the rest of input.
+		rv.SetBytes(data[:len(data):len(data)])
 		return nil, nil
 	}
 
-	return unmarshalBasicSlice(data, rv, m, path)
+	return d.unmarshalBasicSlice(data, rv, m)
 }
 
-func unmarshalBasicSlice(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshalBasicSlice(data T8L16, rv reflect.Value, m Map) ([]byte, error) {
+	// The fixed sized values are given exactly own data,
+	// so the strict mode does not fail on data for the next items
+	s := basicSize(rv.Type().Elem().Kind())
 	for len(data) > 0 {
 		v := reflect.Indirect(reflect.New(rv.Type().Elem()))
 
-		rest, err := unmarshal(data, v, m, path)
+		value := data
+		if s != 0 && len(value) > s {
+			value = value[:s]
+		}
+		left, err := d.unmarshal(value, v, m)
 		if err != nil {
-			return data, errors.WithStack(err)
+			return data, err
 		}
 
+		if err := d.appendValue(rv); err != nil {
+			return data, err
+		}
 		rv.Set(reflect.Append(rv, v))
-		data = rest
+		data = data[len(value)-len(left):]
 	}
 
 	return nil, nil
@@ -90,83 +329,128 @@ func unmarshalBasicSlice(data T8L16, rv reflect.Value, m Map, path []byte) ([]by
 
 // The unmarshalComplexSlice reads out of data TLV elements
 // one by one, unmarshal and append those to rv.
-func unmarshalComplexSlice(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshalComplexSlice(data T8L16, rv reflect.Value, m Map) ([]byte, error) {
+	zero := reflect.Zero(rv.Type().Elem())
+	if m == nil && zero.Kind() == reflect.Interface {
+		pm, err := d.pathMap(d.path)
+		if err != nil {
+			return data, d.fail(data, err)
+		}
+		m = pm
+	}
 	for len(data) > 0 {
-		v := reflect.Indirect(reflect.New(rv.Type().Elem()))
-
 		_, value, rest, err := data.Read()
 		if err != nil {
-			return data, errors.WithStack(err)
+			return data, d.fail(data, err)
 		}
 		value = data[0 : 3+len(value)]
 
-		left, err := unmarshal(value, v, m, path)
+		// Decode in place of appended item,
+		// and drop the item in case of failure
+		if err := d.appendValue(rv); err != nil {
+			return data, d.fail(data, err)
+		}
+		n := rv.Len()
+		rv.Set(reflect.Append(rv, zero))
+		left, err := d.unmarshal(value, rv.Index(n), m)
+		if err == nil && len(left) != 0 {
+			err = &UnprocessedDataError{Data: left}
+		}
 		if err != nil {
-			return data, errors.WithStack(err)
+			rv.SetLen(n)
+			return data, d.fail(data, err)
 		}
-		if len(left) != 0 {
-			return data, &UnprocessedDataError{left}
+		// The skipped unknown TLV leaves the interface nil
+		if item := rv.Index(n); item.Kind() == reflect.Interface && item.IsNil() {
+			rv.SetLen(n)
 		}
 
-		rv.Set(reflect.Append(rv, v))
 		data = rest
 	}
 
 	return nil, nil
 }
 
-func unmarshalValue(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshalValue(data T8L16, rv reflect.Value, m Map) ([]byte, error) {
 	// The rv might be basic type.
 	// In such case the m must be nil,
 	// and we shall just unmarshal value.
 	if isBasicType(rv) && m == nil {
-		return unmarshalBasicType(data, rv, path)
+		rest, err := d.unmarshalBasicType(data, rv)
+		if err == nil && d.opts.Strict {
+			err = checkEnum(rv)
+		}
+		return rest, err
 	}
 	if isString(rv) && m == nil {
-		return unmarshalString(data, rv, path)
+		return d.unmarshalString(data, rv)
 	}
 	if isByteArray(rv) && m == nil {
-		return unmarshalByteArray(data, rv, path)
+		return d.unmarshalByteArray(data, rv)
 	}
 
 	if isTime(rv) && m == nil {
-		return unmarshalTime(data, rv, path)
+		return d.unmarshalTime(data, rv)
 	}
 
 	if isInterface(rv) {
-		return unmarshalInterface(data, rv, m, path)
+		return d.unmarshalInterface(data, rv, m)
+	}
+	if isStruct(rv) && m == nil {
+		bf, err := getBitFields(rv.Type())
+		if err != nil {
+			return data, err
+		}
+		if bf != nil {
+			return nil, unmarshalBits(data, rv, bf)
+		}
 	}
 	if isStruct(rv) {
-		return unmarshalStruct(data, rv, m, path)
+		return d.unmarshalStruct(data, rv, m)
 	}
 
-	return data, &WrongKindError{rv.Kind(), path}
+	return data, &WrongKindError{Kind: rv.Kind()}
 }
 
-func unmarshalInterface(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshalInterface(data T8L16, rv reflect.Value, m Map) ([]byte, error) {
 	if m == nil {
-		rv.Set(reflect.ValueOf(data))
+		rv.Set(reflect.ValueOf(data[:len(data):len(data)]))
 		return nil, nil
 	}
 
 	// Read T and V
 	t, v, rest, err := data.Read()
 	if err != nil {
-		return data, errors.WithStack(err)
+		return data, d.fail(data, err)
 	}
 
 	// Find storage type for T
+	others := false
 	r, ok := m[t]
 	if !ok {
+		reported := d.unknown(t, data[0:len(v)+3])
 		r, ok = m[AllOthers]
+		if !ok && reported {
+			// The skipped TLV is within the limits anyway
+			if err := d.push(t, ""); err != nil {
+				return data, d.fail(data, err)
+			}
+			d.pop()
+			return rest, nil
+		}
 		if !ok {
-			return data, ErrTlvMapHasNoEntry{Path: append(path, t)}
+			_ = d.push(t, "")
+			return data, d.fail(data, ErrTlvMapHasNoEntry)
 		}
 		// In case of allOthers we shall not loose type info,
 		// so prepend tl to v
 		v = data[0 : len(v)+3]
+		others = true
 	}
 
+	if err := d.budget.Value(); err != nil {
+		return data, d.fail(data, err)
+	}
 	pi := reflect.New(r.T)
 	i := reflect.Indirect(pi)
 
@@ -175,94 +459,176 @@ func unmarshalInterface(data T8L16, rv reflect.Value, m Map, path []byte) ([]byt
 		umi.SetTLVType(t)
 	}
 
-	// When unmarshal to interface, the map shall not propagade
-	left, err := unmarshal(v, i, nil, append(path, t))
-	if err != nil {
-		return data, errors.WithStack(err)
+	// When unmarshal to interface, the map shall not propagade,
+	// but the nested interface values have own Map of PathMap
+	if err := d.push(t, r.K); err != nil {
+		return data, d.fail(data, err)
 	}
-	if len(left) != 0 {
-		return data, &UnprocessedDataError{left}
+	var im Map
+	if isInterfaceType(r.T) {
+		path := d.path
+		if others {
+			path = path[:len(path)-1]
+		}
+		if im, err = d.pathMap(path); err != nil {
+			return data, d.fail(data, err)
+		}
 	}
+	left, err := d.unmarshal(v, i, im)
+	if err == nil && len(left) != 0 {
+		err = &UnprocessedDataError{Data: left}
+	}
+	if err != nil {
+		return data, d.fail(data, err)
+	}
+	d.pop()
 
 	rv.Set(i)
 	return rest, nil
 }
 
-func unmarshalStruct(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshalStruct(data T8L16, rv reflect.Value, m Map) ([]byte, error) {
 	// For non-basic types we shall have map.
-	// If map m is not given, try to get it.
-	if m == nil {
-		var err error
-		if m, err = getTlvMap(rv.Type()); err != nil {
-			return data, errors.WithStack(err)
-		}
-		if m == nil {
-			return data, ErrNoTlvMap
-		}
+	// If map m is not given, use the cached plan out of struct tags.
+	// Otherwise, use the cached plan of m.
+	if m != nil {
+		return d.unmarshalPlan(data, rv, getHintPlan(rv.Type(), m))
+	}
+
+	p, err := getPlan(rv.Type())
+	if err != nil {
+		return data, err
 	}
+	return d.unmarshalPlan(data, rv, p)
+}
 
+func (d *decodeState) unmarshalPlan(data T8L16, rv reflect.Value, p *structPlan) ([]byte, error) {
 	// Try to obtain Unmarshaler interface
-	umi, _ := rv.Addr().Interface().(Unmarshaler)
+	var umi Unmarshaler
+	if p.unmarshaler {
+		umi = rv.Addr().Interface().(Unmarshaler)
+	}
 
-	// Process all data
-	for len(data) > 0 {
+	// The fields already decoded, to find repeated TLVs in strict mode
+	var seen []bool
+	if d.opts.Strict {
+		seen = make([]bool, p.numFields)
+	}
+
+	// Process all data, the index is position of TLV for RawList
+	for index := 0; len(data) > 0; index++ {
 		// Read T and V
 		t, v, rest, err := data.Read()
 		if err != nil {
-			return data, errors.WithStack(err)
+			return data, d.fail(data, err)
 		}
 		l := len(v)
 
 		// Find storage type for T
-		r, ok := m[t]
-		if !ok {
-			r, ok = m[AllOthers]
-			if !ok {
-				return data, ErrTlvMapHasNoEntry{Path: append(path, t)}
+		fp := p.fields[t]
+		if fp == nil || t == AllOthers && !fp.zero {
+			reported := d.unknown(t, data[0:3+l])
+			// The field tagged "0" is not the one of all others
+			if fp = p.fields[AllOthers]; fp != nil && fp.zero {
+				fp = nil
+			}
+			if fp == nil && reported {
+				// The skipped TLV is within the limits anyway
+				if err := d.push(t, ""); err != nil {
+					return data, d.fail(data, err)
+				}
+				d.pop()
+				data = rest
+				continue
+			}
+			if fp == nil {
+				_ = d.push(t, "")
+				return data, d.fail(data, ErrTlvMapHasNoEntry)
 			}
 			// In case of allOthers we shall not loose type info,
 			// so prepend tl to v
 			v = data[0 : 3+l]
 		}
 
-		f := rv.FieldByName(r.K)
-		if !f.IsValid() {
-			return data, &ReflectValueHasNoFieldError{rv, r.K}
+		if err := d.push(t, fp.K); err != nil {
+			return data, d.fail(data, err)
+		}
+		if fp.index == nil {
+			return data, d.fail(data, &ReflectValueHasNoFieldError{Type: rv.Type(), Field: fp.K})
+		}
+		if d.opts.CheckTags && fp.path != nil && !matchTagPath(fp.path, d.path) {
+			return data, d.fail(data, ErrTagMismatch)
+		}
+		if seen != nil && !fp.repeat {
+			if seen[fp.id] {
+				return data, d.fail(data, ErrDuplicateTLV)
+			}
+			seen[fp.id] = true
+		}
+		f, err := d.fieldByIndex(rv, fp.index)
+		if err != nil {
+			return data, d.fail(data, err)
 		}
 		if umi != nil {
-			umi.NotifyTLVType(t, r.K)
+			umi.NotifyTLVType(t, fp.K)
+		}
+
+		// The raw TLV is kept as is with own position
+		if fp.raw {
+			if err := d.appendValue(f); err != nil {
+				return data, d.fail(data, err)
+			}
+			f.Set(reflect.Append(f, reflect.ValueOf(Raw{Index: index, TLV: data[0 : 3+l : 3+l]})))
+			d.pop()
+			data = rest
+			continue
+		}
+
+		// The bit-fields share the value
+		if fp.bits != nil {
+			if err := unmarshalBits(v, rv, fp.bits); err != nil {
+				return data, d.fail(data, err)
+			}
+			d.pop()
+			data = rest
+			continue
 		}
 
 		// If the field is pointer to value then it must be allocated
-		if f.Kind() == reflect.Ptr {
+		if fp.ptr {
 			if f.IsNil() {
-				f.Set(reflect.New(r.T.Elem()))
+				if err := d.budget.Value(); err != nil {
+					return data, d.fail(data, err)
+				}
+				f.Set(reflect.New(f.Type().Elem()))
 			}
 			f = f.Elem()
 		}
 		// If the field is slice of struct...
-		if isSlice(f) && f.Type().Elem().Kind() == reflect.Struct {
-			// ... allocate one struct,
-			// and append to slice.
+		if fp.appendStruct {
+			// ... append one struct to slice.
 			// the following unmarshal shall operate on slice item
-			rv := reflect.Indirect(reflect.New(f.Type().Elem()))
-			f.Set(reflect.Append(f, rv))
+			if err := d.appendValue(f); err != nil {
+				return data, d.fail(data, err)
+			}
+			f.Set(reflect.Append(f, reflect.Zero(f.Type().Elem())))
 			f = f.Index(f.Len() - 1)
 		}
 
 		if l == 0 && umi != nil {
-			umi.EmptyTLVType(t, r.K)
+			umi.EmptyTLVType(t, fp.K)
 		}
-		if len(v) != 0 {
+		if len(v) != 0 || fp.optional {
 			// When unmarshal struct's field, the map shall not propagade
-			left, err := unmarshal(v, f, nil, append(path, t))
-			if err != nil {
-				return data, errors.WithStack(err)
+			left, err := fp.decode(d, v, f)
+			if err == nil && len(left) != 0 {
+				err = &UnprocessedDataError{Data: left}
 			}
-			if len(left) != 0 {
-				return data, &UnprocessedDataError{left}
+			if err != nil {
+				return data, d.fail(data, err)
 			}
 		}
+		d.pop()
 
 		// Process rest of data
 		data = rest
@@ -271,90 +637,140 @@ func unmarshalStruct(data T8L16, rv reflect.Value, m Map, path []byte) ([]byte,
 	return nil, nil
 }
 
-func unmarshalBasicType(data []byte, rv reflect.Value, path []byte) ([]byte, error) { // nolint:gocyclo,unparam
-	s := int(rv.Type().Size())
-	if len(data) < s {
-		// There is not enough data so we shall prepend zeroes
-		zeroes := []byte{0, 0, 0, 0, 0, 0, 0, 0}
-		data = append(zeroes[0:s-len(data)], data...)
+// The fieldByIndex returns field of struct rv by index,
+// and allocates the nil pointers to embedded structs along the way.
+func (d *decodeState) fieldByIndex(rv reflect.Value, index []int) (reflect.Value, error) {
+	for _, i := range index[:len(index)-1] {
+		rv = rv.Field(i)
+		if rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if err := d.budget.Value(); err != nil {
+					return rv, err
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
 	}
+	return rv.Field(index[len(index)-1]), nil
+}
 
-	switch k := rv.Kind(); k {
-	case reflect.Bool:
-		rv.SetBool(data[0] != 0)
-		return data[1:], nil
+func (d *decodeState) unmarshalBasicType(data []byte, rv reflect.Value) ([]byte, error) {
+	k := rv.Kind()
+	s := basicSize(k)
+	if s == 0 {
+		return data, &WrongKindError{Kind: k}
+	}
+	if len(data) > s && d.opts.Strict {
+		return data, ErrValueTooLong
+	}
+	if len(data) < s && d.opts.Strict {
+		return data, ErrNotEnoughData
+	}
 
-	case reflect.Int8:
-		rv.SetInt(int64(data[0]))
-		return data[1:], nil
+	value := data
+	if len(value) > s {
+		value = value[:s]
+	}
+	rest := data[len(value):]
 
-	case reflect.Int16:
-		rv.SetInt(int64(binary.NetworkByteOrder.Uint16(data)))
-		return data[2:], nil
+	var u uint64
+	for _, b := range value {
+		u = u<<8 | uint64(b)
+	}
 
-	case reflect.Int32:
-		rv.SetInt(int64(binary.NetworkByteOrder.Uint32(data)))
-		return data[4:], nil
+	switch k {
+	case reflect.Bool:
+		rv.SetBool(u != 0)
 
-	case reflect.Int64:
-		rv.SetInt(int64(binary.NetworkByteOrder.Uint64(data)))
-		return data[8:], nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Sign extension of shorter data
+		if len(value) > 0 && len(value) < 8 && value[0]&0x80 != 0 {
+			u |= ^uint64(0) << (8 * uint(len(value)))
+		}
+		rv.SetInt(int64(u))
 
-	case reflect.Uint8:
-		rv.SetUint(uint64(data[0]))
-		return data[1:], nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		rv.SetUint(u)
 
-	case reflect.Uint16:
-		rv.SetUint(uint64(binary.NetworkByteOrder.Uint16(data)))
-		return data[2:], nil
+	case reflect.Float32:
+		if len(value) < s {
+			return data, ErrNotEnoughData
+		}
+		rv.SetFloat(float64(math.Float32frombits(uint32(u))))
 
-	case reflect.Uint32:
-		rv.SetUint(uint64(binary.NetworkByteOrder.Uint32(data)))
-		return data[4:], nil
+	case reflect.Float64:
+		if len(value) < s {
+			return data, ErrNotEnoughData
+		}
+		rv.SetFloat(math.Float64frombits(u))
+	}
 
-	case reflect.Uint64:
-		rv.SetUint(binary.NetworkByteOrder.Uint64(data))
-		return data[8:], nil
+	return rest, nil
+}
 
-	default:
-		return data, &WrongKindError{k, path}
-	}
+// The basicSize returns size in octets of TLV value for basic kind k,
+// or 0 if the kind is not supported.
+func basicSize(k reflect.Kind) int {
+	switch k {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1
+	case reflect.Int16, reflect.Uint16:
+		return 2
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4
+	case reflect.Int, reflect.Uint, reflect.Int64, reflect.Uint64, reflect.Float64:
+		return 8
+	}
+	return 0
 }
 
-func unmarshalString(data []byte, rv reflect.Value, path []byte) ([]byte, error) { // nolint:unparam
+func (d *decodeState) unmarshalString(data []byte, rv reflect.Value) ([]byte, error) { // nolint:unparam
 	switch k := rv.Kind(); k {
 	case reflect.String:
+		if err := d.budget.StringLen(len(data)); err != nil {
+			return data, err
+		}
 		rv.SetString(string(data))
 		return nil, nil
 
 	default:
-		return nil, &WrongKindError{k, path}
+		return nil, &WrongKindError{Kind: k}
 	}
 }
 
-func unmarshalByteArray(data []byte, rv reflect.Value, path []byte) ([]byte, error) { // nolint:unparam
+func (d *decodeState) unmarshalByteArray(data []byte, rv reflect.Value) ([]byte, error) { // nolint:unparam
 	switch k := rv.Kind(); k {
 	case reflect.Array:
+		if d.opts.Strict && len(data) < rv.Len() {
+			return data, ErrNotEnoughData
+		}
+		if d.opts.Strict && len(data) > rv.Len() {
+			return data, ErrValueTooLong
+		}
 		reflect.Copy(rv, reflect.ValueOf(data))
 		return nil, nil
 
 	default:
-		return nil, &WrongKindError{k, path}
+		return nil, &WrongKindError{Kind: k}
 	}
 }
 
-func unmarshalTime(data []byte, rv reflect.Value, path []byte) ([]byte, error) {
+func (d *decodeState) unmarshalTime(data []byte, rv reflect.Value) ([]byte, error) {
 	if len(data) < 8 {
 		return data, ErrNotEnoughData
 	}
 
 	year := int(data[0])<<8 | int(data[1])
+	if year < 1 || year > 9999 {
+		return data, ErrBadTime
+	}
 	if data[2] < 1 || data[2] > 12 {
 		return data, ErrBadTime
 	}
 	month := time.Month(data[2])
 	day := int(data[3])
-	if day < 1 || day > 31 {
+	if day < 1 || day > daysIn(month, year) {
 		return data, ErrBadTime
 	}
 	hour := int(data[4])
@@ -395,3 +811,9 @@ func unmarshalTime(data []byte, rv reflect.Value, path []byte) ([]byte, error) {
 
 	return data, nil
 }
+
+// The daysIn returns number of days in month of year
+func daysIn(month time.Month, year int) int {
+	// The day 0 of next month is the last day of month
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
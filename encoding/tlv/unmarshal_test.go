@@ -2,6 +2,7 @@ package tlv
 
 import (
 	"bytes"
+	"io"
 	"net"
 	"reflect"
 	"testing"
@@ -58,7 +59,7 @@ func TestLowUnmarshalValue(t *testing.T) {
 		v := reflect.Indirect(reflect.New(t)) // The v is T
 		assert.NotNil(v)
 
-		rest, err := unmarshal(bytes, v, nil, []byte{})
+		rest, err := (&decodeState{}).unmarshal(bytes, v, nil)
 		if assert.NoError(err) && assert.Len(rest, 0) {
 			assert.Equal(value, v.Interface())
 		}
@@ -128,7 +129,7 @@ func TestLowUnmarshalStuct1(t *testing.T) {
 	r := &TestStruct6{}
 	rv := reflect.Indirect(reflect.ValueOf(r))
 	data := []byte{1, 0, 1, 1, 2, 0, 1, 2} // manually crafter data
-	rest, err := unmarshalStruct(data, rv, nil, []byte{})
+	rest, err := (&decodeState{}).unmarshalStruct(data, rv, nil)
 	assert.NoError(err)
 	assert.Empty(rest)
 	assert.EqualValues(1, r.A)
@@ -140,7 +141,7 @@ func TestLowUnmarshalStuct2(t *testing.T) {
 	r := &TestStruct6{}
 	rv := reflect.Indirect(reflect.ValueOf(r))
 	data := []byte{3, 0, 6, 1, 2, 3, 4, 5, 6} // manually crafter data
-	rest, err := unmarshalStruct(data, rv, nil, []byte{})
+	rest, err := (&decodeState{}).unmarshalStruct(data, rv, nil)
 	assert.NoError(err)
 	assert.Empty(rest)
 	assert.Equal([]byte{1, 2, 3, 4, 5, 6}, r.C)
@@ -151,7 +152,7 @@ func TestLowUnmarshalStuct3(t *testing.T) {
 	r := &TestStruct6{}
 	rv := reflect.Indirect(reflect.ValueOf(r))
 	data := []byte{4, 0, 8, 1, 0, 1, 1, 2, 0, 1, 2} // manually crafter data
-	rest, err := unmarshalStruct(data, rv, nil, []byte{})
+	rest, err := (&decodeState{}).unmarshalStruct(data, rv, nil)
 	assert.NoError(err)
 	assert.Empty(rest)
 	if assert.NotNil(r.D) {
@@ -165,7 +166,7 @@ func TestLowUnmarshalStuct4(t *testing.T) {
 	r := &TestStruct6{}
 	rv := reflect.Indirect(reflect.ValueOf(r))
 	data := []byte{5, 0, 8, 1, 0, 1, 1, 2, 0, 1, 2, 5, 0, 8, 1, 0, 1, 3, 2, 0, 1, 4} // manually crafter data
-	rest, err := unmarshalStruct(data, rv, nil, []byte{})
+	rest, err := (&decodeState{}).unmarshalStruct(data, rv, nil)
 	assert.NoError(err)
 	assert.Empty(rest)
 	if assert.Len(r.E, 2) {
@@ -187,7 +188,7 @@ func TestLowUnmarshalInterface(t *testing.T) {
 	hint := Map{
 		byte(6): {T: reflect.TypeOf(TestStruct6{})},
 	}
-	rest, err := unmarshalInterface(data, rv, hint, []byte{})
+	rest, err := (&decodeState{}).unmarshalInterface(data, rv, hint)
 	assert.NoError(err)
 	assert.Empty(rest)
 	if assert.IsType(TestStruct6{}, r) {
@@ -220,3 +221,294 @@ func TestUnmarshalToInterfaceWithHint(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalBasicTypeShortData(t *testing.T) {
+	assert := assert.New(t)
+
+	var i16 int16
+	rest, err := Unmarshal(T8L16{0xFF}, &i16)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(int16(-1), i16)
+
+	var i32 int32
+	_, err = Unmarshal(T8L16{0x80, 0x00}, &i32)
+	assert.NoError(err)
+	assert.Equal(int32(-32768), i32)
+	_, err = Unmarshal(T8L16{0x7F, 0xFF}, &i32)
+	assert.NoError(err)
+	assert.Equal(int32(32767), i32)
+
+	var u32 uint32
+	_, err = Unmarshal(T8L16{0xFF}, &u32)
+	assert.NoError(err)
+	assert.Equal(uint32(255), u32)
+
+	var i int
+	_, err = Unmarshal(T8L16{0xFF, 0xFE}, &i)
+	assert.NoError(err)
+	assert.Equal(-2, i)
+
+	var u uint
+	rest, err = Unmarshal(T8L16{0, 0, 0, 0, 0, 0, 0x12, 0x34, 0x56}, &u)
+	assert.NoError(err)
+	assert.Equal([]byte{0x56}, rest)
+	assert.Equal(uint(0x1234), u)
+}
+
+func TestUnmarshalBasicTypeFloat(t *testing.T) {
+	assert := assert.New(t)
+
+	var f32 float32
+	rest, err := Unmarshal(T8L16{0x3F, 0xC0, 0x00, 0x00}, &f32)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(float32(1.5), f32)
+
+	var f64 float64
+	rest, err = Unmarshal(T8L16{0xC0, 0x04, 0, 0, 0, 0, 0, 0}, &f64)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(float64(-2.5), f64)
+
+	_, err = Unmarshal(T8L16{0x3F, 0xC0}, &f32)
+	assert.ErrorIs(err, ErrNotEnoughData)
+
+	var c complex64
+	_, err = Unmarshal(T8L16{0, 0, 0, 0, 0, 0, 0, 0}, &c)
+	var wk *WrongKindError
+	assert.ErrorAs(err, &wk)
+}
+
+func TestUnmarshalBasicTypeStrict(t *testing.T) {
+	assert := assert.New(t)
+	strict := DecodeOptions{Strict: true}
+
+	var u16 uint16
+	_, err := UnmarshalWithOptions(T8L16{1, 2, 3}, &u16, strict)
+	assert.ErrorIs(err, ErrValueTooLong)
+
+	rest, err := UnmarshalWithOptions(T8L16{1, 2}, &u16, strict)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(uint16(0x0102), u16)
+
+	// The slice items are not affected
+	var a []uint16
+	rest, err = UnmarshalWithOptions(T8L16{1, 2, 3, 4}, &a, strict)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal([]uint16{0x0102, 0x0304}, a)
+
+	var v TestStructWithOptionalFields
+	_, err = UnmarshalWithOptions(T8L16{2, 0, 5, 0, 0, 0, 1, 2}, &v, strict)
+	assert.Error(err)
+}
+
+func TestUnmarshalDecodeError(t *testing.T) {
+	assert := assert.New(t)
+
+	type Core struct {
+		Index uint8   `tlv:"1"`
+		Rate  float32 `tlv:"2"`
+	}
+	type Message struct {
+		Version uint8  `tlv:"1"`
+		Cores   []Core `tlv:"9"`
+	}
+
+	data := T8L16{
+		1, 0, 1, 1,
+		9, 0, 4, 1, 0, 1, 5,
+		9, 0, 9, 1, 0, 1, 6, 2, 0, 2, 0, 0,
+	}
+	var v Message
+	_, err := Unmarshal(data, &v)
+	assert.ErrorIs(err, ErrNotEnoughData)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal(18, de.Offset)
+		assert.Equal([]int{9, 2}, de.Path)
+		assert.Equal([]string{"Cores", "Rate"}, de.Names)
+		assert.Equal("Cores(9)/Rate(2) at offset 18: not enough data", err.Error())
+	}
+
+	// The unknown TLV type is located by its own type
+	_, err = Unmarshal(T8L16{9, 0, 4, 7, 0, 1, 1}, &v)
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+	if assert.ErrorAs(err, &de) {
+		assert.Equal(3, de.Offset)
+		assert.Equal([]int{9, 7}, de.Path)
+	}
+
+	// The broken framing
+	_, err = Unmarshal(T8L16{1, 0, 1, 1, 9, 0, 4, 1}, &v)
+	assert.ErrorIs(err, io.ErrShortBuffer)
+	if assert.ErrorAs(err, &de) {
+		assert.Equal(4, de.Offset)
+		assert.Empty(de.Path)
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	assert := assert.New(t)
+	strict := DecodeOptions{Strict: true}
+
+	type Core struct {
+		Index uint8 `tlv:"1"`
+	}
+	type Message struct {
+		Version uint16   `tlv:"1"`
+		MAC     [6]byte  `tlv:"2"`
+		Name    string   `tlv:"3"`
+		Ports   []uint16 `tlv:"4"`
+		Cores   []Core   `tlv:"9"`
+	}
+
+	// The short values are zero extended by default
+	var v Message
+	_, err := Unmarshal(T8L16{1, 0, 1, 5}, &v)
+	assert.NoError(err)
+	assert.Equal(uint16(5), v.Version)
+
+	_, err = UnmarshalWithOptions(T8L16{1, 0, 1, 5}, &v, strict)
+	assert.ErrorIs(err, ErrNotEnoughData)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{1}, de.Path)
+	}
+
+	// The empty TLV is fine
+	v = Message{}
+	_, err = UnmarshalWithOptions(T8L16{1, 0, 0}, &v, strict)
+	assert.NoError(err)
+
+	// The byte arrays have exact length
+	_, err = UnmarshalWithOptions(T8L16{2, 0, 5, 1, 2, 3, 4, 5}, &v, strict)
+	assert.ErrorIs(err, ErrNotEnoughData)
+	_, err = UnmarshalWithOptions(T8L16{2, 0, 7, 1, 2, 3, 4, 5, 6, 7}, &v, strict)
+	assert.ErrorIs(err, ErrValueTooLong)
+	_, err = UnmarshalWithOptions(T8L16{2, 0, 6, 1, 2, 3, 4, 5, 6}, &v, strict)
+	assert.NoError(err)
+	assert.Equal([6]byte{1, 2, 3, 4, 5, 6}, v.MAC)
+
+	// The last item of basic slice is short
+	v = Message{}
+	_, err = UnmarshalWithOptions(T8L16{4, 0, 3, 0, 1, 2}, &v, strict)
+	assert.ErrorIs(err, ErrNotEnoughData)
+
+	// The repeated TLV overwrites the field by default
+	data := T8L16{1, 0, 2, 0, 1, 3, 0, 1, 'a', 1, 0, 2, 0, 2}
+	v = Message{}
+	_, err = Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Equal(uint16(2), v.Version)
+
+	v = Message{}
+	_, err = UnmarshalWithOptions(data, &v, strict)
+	assert.ErrorIs(err, ErrDuplicateTLV)
+	if assert.ErrorAs(err, &de) {
+		assert.Equal(9, de.Offset)
+		assert.Equal("Version(1) at offset 9: duplicate tlv", err.Error())
+	}
+
+	// The slices are repeatable
+	data = T8L16{
+		4, 0, 2, 0, 1,
+		4, 0, 2, 0, 2,
+		9, 0, 4, 1, 0, 1, 1,
+		9, 0, 4, 1, 0, 1, 2,
+	}
+	v = Message{}
+	_, err = UnmarshalWithOptions(data, &v, strict)
+	assert.NoError(err)
+	assert.Equal([]uint16{1, 2}, v.Ports)
+	assert.Equal([]Core{{1}, {2}}, v.Cores)
+
+	// The nested struct is checked on its own
+	_, err = UnmarshalWithOptions(T8L16{9, 0, 8, 1, 0, 1, 1, 1, 0, 1, 2}, &v, strict)
+	assert.ErrorIs(err, ErrDuplicateTLV)
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{9, 1}, de.Path)
+	}
+}
+
+func TestUnmarshalUnknown(t *testing.T) {
+	assert := assert.New(t)
+
+	type Core struct {
+		Index uint8 `tlv:"1"`
+	}
+	type Message struct {
+		Version uint8  `tlv:"1"`
+		Cores   []Core `tlv:"9"`
+	}
+
+	data := T8L16{
+		1, 0, 1, 1,
+		7, 0, 1, 7,
+		9, 0, 8, 1, 0, 1, 5, 8, 0, 1, 8,
+	}
+	var v Message
+	_, err := Unmarshal(data, &v)
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+
+	var unknown []UnknownTLV
+	v = Message{}
+	rest, err := UnmarshalWithOptions(data, &v, DecodeOptions{Unknown: &unknown})
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(Message{Version: 1, Cores: []Core{{5}}}, v)
+	assert.Equal([]UnknownTLV{
+		{Offset: 4, Path: []int{7}, Names: []string{""}, TLV: T8L16{7, 0, 1, 7}},
+		{Offset: 15, Path: []int{9, 8}, Names: []string{"Cores", ""}, TLV: T8L16{8, 0, 1, 8}},
+	}, unknown)
+
+	// The AllOthers catches the unknown TLVs, and those are reported too
+	type Others struct {
+		Version uint8         `tlv:"1"`
+		Others  []interface{} `tlv:"others"`
+	}
+	unknown = nil
+	var o Others
+	_, err = UnmarshalWithOptions(data[:8], &o, DecodeOptions{Unknown: &unknown})
+	assert.NoError(err)
+	assert.Equal([]interface{}{T8L16{7, 0, 1, 7}}, o.Others)
+	assert.Len(unknown, 1)
+
+	// The slice of interfaces drops the unknown TLVs
+	m := Map{1: {K: "Version", T: reflect.TypeOf(uint8(0))}}
+	unknown = nil
+	var items []interface{}
+	_, err = UnmarshalWithOptions(data[:8], &items, DecodeOptions{Unknown: &unknown}, m)
+	assert.NoError(err)
+	assert.Equal([]interface{}{uint8(1)}, items)
+	assert.Len(unknown, 1)
+
+	// The unknown TLVs are within limits
+	unknown = nil
+	opts := DecodeOptions{Unknown: &unknown, Limits: Limits{MaxElements: 2}}
+	_, err = UnmarshalWithOptions(T8L16{7, 0, 0, 7, 0, 0, 7, 0, 0}, &v, opts)
+	assert.ErrorIs(err, ErrLimitExceeded)
+}
+
+func TestWrapDecodeError(t *testing.T) {
+	assert := assert.New(t)
+
+	// The error of value is located at TLV
+	err := WrapDecodeError(ErrValueTooLong, 5, 7)
+	assert.ErrorIs(err, ErrValueTooLong)
+	assert.EqualError(err, "5 at offset 7: value too long")
+
+	// The error within value is located within parent
+	err = WrapDecodeError(&DecodeError{Offset: 4, Path: []int{2}, Names: []string{"Name"}, Err: ErrNotEnoughData}, 9, 10)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal(DecodeError{Offset: 17, Path: []int{9, 2}, Names: []string{"", "Name"}, Err: ErrNotEnoughData}, *de)
+	}
+	err = WrapDecodeError(&DecodeError{Offset: -1, Err: ErrLimitExceeded}, 9, 10)
+	if assert.ErrorAs(err, &de) {
+		assert.Equal(-1, de.Offset)
+		assert.Equal([]int{9}, de.Path)
+	}
+}
@@ -0,0 +1,84 @@
+package tlv
+
+// This file has helpers to decode and encode TLV values without reflection.
+// Those are used by generated code (see cmd/tlvgen).
+
+import (
+	"time"
+
+	"github.com/cloudcopper/core/encoding/binary"
+)
+
+// DecodeUint decodes unsigned integer value of size octets in network byte order.
+// The shorter value is zero extended, and the longer value is an error.
+func DecodeUint(v []byte, size int) (uint64, error) {
+	if len(v) > size {
+		return 0, ErrValueTooLong
+	}
+	var u uint64
+	for _, b := range v {
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+// DecodeInt decodes signed integer value of size octets in network byte order.
+// The shorter value is sign extended, and the longer value is an error.
+func DecodeInt(v []byte, size int) (int64, error) {
+	u, err := DecodeUint(v, size)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) > 0 && len(v) < 8 && v[0]&0x80 != 0 {
+		u |= ^uint64(0) << (8 * uint(len(v)))
+	}
+	return int64(u), nil
+}
+
+// AppendUint appends u as size octets in network byte order to dst
+func AppendUint(dst []byte, u uint64, size int) []byte {
+	for i := size - 1; i >= 0; i-- {
+		dst = append(dst, byte(u>>(8*uint(i))))
+	}
+	return dst
+}
+
+// AppendBool appends b as single octet 1 or 0 to dst
+func AppendBool(dst []byte, b bool) []byte {
+	if b {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+// AppendTime appends t as DateAndTime (RFC 2579) to dst.
+// The time in UTC takes 8 octets, and any other location
+// takes 11 octets with the offset from UTC.
+// The time is truncated to deci-seconds.
+func AppendTime(dst []byte, t time.Time) []byte {
+	dst = AppendUint(dst, uint64(t.Year()), 2)
+	dst = append(dst, byte(t.Month()), byte(t.Day()),
+		byte(t.Hour()), byte(t.Minute()), byte(t.Second()),
+		byte(t.Nanosecond()/100_000_000))
+	if t.Location() == time.UTC {
+		return dst
+	}
+
+	_, offset := t.Zone()
+	direction := byte('+')
+	if offset < 0 {
+		direction = '-'
+		offset = -offset
+	}
+	return append(dst, direction, byte(offset/3600), byte(offset%3600/60))
+}
+
+// AppendT8L16 appends TLV of type t and value v to dst
+func AppendT8L16(dst []byte, t byte, v []byte) ([]byte, error) {
+	if len(v) > 0xFFFF {
+		return dst, ErrValueTooLong
+	}
+	dst = append(dst, t, 0, 0)
+	binary.NetworkByteOrder.PutUint16(dst[len(dst)-2:], uint16(len(v)))
+	return append(dst, v...), nil
+}
@@ -0,0 +1,30 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueHelpers(t *testing.T) {
+	assert := assert.New(t)
+
+	u, err := DecodeUint([]byte{0x12, 0x34}, 4)
+	assert.NoError(err)
+	assert.Equal(uint64(0x1234), u)
+	_, err = DecodeUint([]byte{1, 2, 3}, 2)
+	assert.Equal(ErrValueTooLong, err)
+
+	i, err := DecodeInt([]byte{0xFF, 0xFE}, 4)
+	assert.NoError(err)
+	assert.Equal(int64(-2), i)
+
+	assert.Equal([]byte{0, 0, 0x12, 0x34}, AppendUint(nil, 0x1234, 4))
+	assert.Equal([]byte{9, 1}, AppendBool([]byte{9}, true))
+
+	b, err := AppendT8L16([]byte{1}, 2, []byte{3, 4})
+	assert.NoError(err)
+	assert.Equal([]byte{1, 2, 0, 2, 3, 4}, b)
+	_, err = AppendT8L16(nil, 2, make([]byte, 0x10000))
+	assert.Equal(ErrValueTooLong, err)
+}
@@ -0,0 +1,111 @@
+package tlv
+
+// This file has support of vendor-specific TLVs.
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// VendorSpecific is the Go value of vendor-specific TLV,
+// which content depends on the vendor (i.e. DOCSIS TLV 43).
+// The struct field of this type has tag `vendor:"N"` next to `tlv` tag,
+// where N is TLV type of vendor ID, which shall be the first TLV of content.
+//
+//	Vendor tlv.VendorSpecific `tlv:"43" vendor:"8"`
+//
+// The TLVs following vendor ID are decoded to the Go type registered
+// for the vendor by RegisterVendor. The content of unknown vendor is kept as is.
+type VendorSpecific struct {
+	ID      uint32      // the vendor ID
+	Value   interface{} // pointer to registered Go type of vendor, or nil for unknown vendor
+	Unknown RawList     // the TLVs of unknown vendor, indexed after vendor ID
+}
+
+// RegisterVendor registers Go type t of vendor-specific TLVs of vendor id.
+// The t shall be decodable out of TLVs, i.e. struct with `tlv` tags.
+// Registering nil t removes the vendor.
+func RegisterVendor(id uint32, t reflect.Type) {
+	if t == nil {
+		vendors.Delete(id)
+		return
+	}
+	vendors.Store(id, t)
+}
+
+var vendors sync.Map // map[uint32]reflect.Type
+
+// The parseVendorTag parses `vendor:"N"` struct tag
+func parseVendorTag(sf reflect.StructField, tag string) (byte, error) {
+	n, err := strconv.ParseUint(tag, 0, 8)
+	if err != nil || n == 0 {
+		return 0, ErrBadVendorTag
+	}
+	t := sf.Type
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t != vendorSpecificType {
+		return 0, ErrBadVendorTag
+	}
+	return byte(n), nil
+}
+
+// The vendorDecoder returns decoder of VendorSpecific with vendor ID of TLV type id
+func vendorDecoder(id byte) decodeFunc {
+	return func(d *decodeState, data T8L16, rv reflect.Value) ([]byte, error) {
+		return d.unmarshalVendor(data, rv, id)
+	}
+}
+
+// The unmarshalVendor decodes content of vendor-specific TLV to VendorSpecific rv.
+func (d *decodeState) unmarshalVendor(data T8L16, rv reflect.Value, id byte) ([]byte, error) {
+	t, v, rest, err := data.Read()
+	if err != nil {
+		return data, err
+	}
+	if t != id {
+		return data, ErrNoVendorID
+	}
+	u, err := DecodeUint(v, 4)
+	if err != nil {
+		return data, err
+	}
+	vs := rv.Addr().Interface().(*VendorSpecific)
+	*vs = VendorSpecific{ID: uint32(u)}
+
+	if vt, ok := vendors.Load(vs.ID); ok {
+		if err := d.budget.Value(); err != nil {
+			return data, err
+		}
+		pv := reflect.New(vt.(reflect.Type))
+		left, err := d.unmarshal(rest, pv.Elem(), nil)
+		if err == nil && len(left) != 0 {
+			err = &UnprocessedDataError{Data: left}
+		}
+		if err != nil {
+			return data, err
+		}
+		vs.Value = pv.Interface()
+		return nil, nil
+	}
+
+	// The content of unknown vendor is kept as is
+	list := reflect.ValueOf(&vs.Unknown).Elem()
+	for index := 1; len(rest) > 0; index++ {
+		_, v, next, err := rest.Read()
+		if err != nil {
+			return data, d.fail(rest, err)
+		}
+		if err := d.appendValue(list); err != nil {
+			return data, d.fail(rest, err)
+		}
+		l := 3 + len(v)
+		vs.Unknown = append(vs.Unknown, Raw{Index: index, TLV: rest[:l:l]})
+		rest = next
+	}
+	return nil, nil
+}
+
+var vendorSpecificType = reflect.TypeOf(VendorSpecific{})
@@ -0,0 +1,84 @@
+package tlv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestVendorCableLabs struct {
+	Mode  uint8  `tlv:"1"`
+	Label string `tlv:"2"`
+}
+
+type TestStructVendor struct {
+	Version uint8            `tlv:"1"`
+	Vendor  VendorSpecific   `tlv:"43" vendor:"8"`
+	Vendors []VendorSpecific `tlv:"44" vendor:"8"`
+}
+
+func TestUnmarshalVendor(t *testing.T) {
+	assert := assert.New(t)
+	RegisterVendor(4491, reflect.TypeOf(TestVendorCableLabs{}))
+	defer RegisterVendor(4491, nil)
+
+	data := T8L16{
+		1, 0, 1, 1,
+		43, 0, 13, 8, 0, 2, 0x11, 0x8B, 1, 0, 1, 3, 2, 0, 1, 'a',
+		44, 0, 9, 8, 0, 3, 0, 0, 7, 5, 0, 0,
+		44, 0, 5, 8, 0, 2, 0x11, 0x8B,
+	}
+	var v TestStructVendor
+	rest, err := Unmarshal(data, &v)
+	assert.NoError(err)
+	assert.Empty(rest)
+	assert.Equal(uint32(4491), v.Vendor.ID)
+	assert.Equal(&TestVendorCableLabs{Mode: 3, Label: "a"}, v.Vendor.Value)
+	assert.Empty(v.Vendor.Unknown)
+
+	// The unknown vendor is kept as is
+	if assert.Len(v.Vendors, 2) {
+		assert.Equal(uint32(7), v.Vendors[0].ID)
+		assert.Nil(v.Vendors[0].Value)
+		assert.Equal(RawList{{Index: 1, TLV: T8L16{5, 0, 0}}}, v.Vendors[0].Unknown)
+		assert.Equal(T8L16{8, 0, 3, 0, 0, 7, 5, 0, 0}, v.Vendors[0].Unknown.Merge(T8L16{8, 0, 3, 0, 0, 7}))
+
+		assert.Equal(&TestVendorCableLabs{}, v.Vendors[1].Value)
+	}
+
+	// The content of vendor is located by path
+	_, err = Unmarshal(T8L16{43, 0, 8, 8, 0, 2, 0x11, 0x8B, 9, 0, 0}, &v)
+	assert.ErrorIs(err, ErrTlvMapHasNoEntry)
+	var de *DecodeError
+	if assert.ErrorAs(err, &de) {
+		assert.Equal([]int{43, 9}, de.Path)
+		assert.Equal(8, de.Offset)
+	}
+
+	// The vendor ID is the first TLV
+	_, err = Unmarshal(T8L16{43, 0, 4, 1, 0, 1, 3}, &v)
+	assert.ErrorIs(err, ErrNoVendorID)
+	_, err = Unmarshal(T8L16{43, 0, 8, 8, 0, 5, 1, 2, 3, 4, 5}, &v)
+	assert.ErrorIs(err, ErrValueTooLong)
+
+	// The unknown vendors are within limits
+	_, err = UnmarshalWithOptions(T8L16{43, 0, 9, 8, 0, 0, 5, 0, 0, 5, 0, 0}, &v, DecodeOptions{Limits: Limits{MaxSliceLen: 1}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+}
+
+func TestVendorTag(t *testing.T) {
+	assert := assert.New(t)
+
+	var bad struct {
+		Vendor uint8 `tlv:"43" vendor:"8"`
+	}
+	_, err := Unmarshal(T8L16{}, &bad)
+	assert.ErrorIs(err, ErrBadVendorTag)
+
+	var zero struct {
+		Vendor *VendorSpecific `tlv:"43" vendor:"0"`
+	}
+	_, err = Unmarshal(T8L16{}, &zero)
+	assert.ErrorIs(err, ErrBadVendorTag)
+}
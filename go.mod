@@ -3,7 +3,6 @@ module github.com/cloudcopper/core
 go 1.25
 
 require (
-	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.7.0
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
@@ -1,99 +1,384 @@
 package tlv
 
 import (
+	"errors"
+	"fmt"
 	"net"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/cloudcopper/core/encoding/binary"
-	"github.com/pkg/errors"
+	"github.com/cloudcopper/core/encoding/tlv"
 	yaml "gopkg.in/yaml.v3"
 )
 
 // Decode YAML into generic TLV structure
 func Decode(str string) (Elements, error) {
+	return DecodeWithOptions(str, DecodeOptions{})
+}
+
+// DecodeOptions controls the decoding of YAML.
+// The zero value is the default behaviour of Decode.
+type DecodeOptions struct {
+	// Dictionary gives definitions to decoded elements.
+	// The values of elements with FlagSet may be given as list of flags,
+	// i.e. "CoreFunction(10): [Principal, Auxiliary]"
+	Dictionary Dictionary
+
+	// Strict makes the keys, which are neither number nor "Text(N)",
+	// and types out of 0..255 an error.
+	// Otherwise such elements are decoded with type 0x100000,
+	// and are skipped by Marshal.
+	Strict bool
+
+	// ResolveNames looks up the bare names as keys (i.e. "SequenceNumber")
+	// in Dictionary, instead of handling them as unknown keys.
+	ResolveNames bool
+
+	// Template enables parameters and repeat construct (see DecodeTemplate).
+	Template bool
+
+	// MetaKeys are the document level keys of metadata (see DecodeAll),
+	// i.e. "direction". If not nil, the other keys are decoded as TLVs.
+	// In strict mode only MetaKeys are metadata, so the typos of TLV keys
+	// (i.e. "REX(2:") are reported instead of being kept as metadata.
+	MetaKeys []string
+
+	// Vars are parameters of template
+	Vars map[string]string
+
+	// Limits bounds the decoding of untrusted YAML.
+	// The MaxElements and MaxChildren count the YAML aliases too,
+	// as each alias gives the elements of its anchor once again,
+	// and the MaxElements counts the iterations of template repeat.
+	// Without MaxElements, the decoding is bounded by 1048576 elements,
+	// so the nested aliases or repeats do not expand without bound.
+	// The MaxStringLen bounds the scalar values,
+	// and MaxSliceLen bounds the flow sequences (i.e. "[0, 1]").
+	// The decoding stops at the first exceeded limit.
+	Limits Limits
+}
+
+// DecodeWithOptions is the Decode controlled by opts.
+// The decoding does not stop at the first error.
+// All errors are returned as DecodeErrors, each with line and column
+// of failed YAML node, offending scalar and path of the element.
+// The elements decoded without errors are returned anyway.
+func DecodeWithOptions(str string, opts DecodeOptions) (Elements, error) {
+	if err := tlv.NewBudget(opts.Limits).Bytes(len(str)); err != nil {
+		return nil, DecodeErrors{{Offset: -1, Err: err}}
+	}
 	node := yaml.Node{}
 	if err := yaml.Unmarshal([]byte(str), &node); err != nil {
-		return nil, errors.WithStack(err)
+		return nil, DecodeErrors{yamlSyntaxError(err)}
+	}
+
+	d := newYamlDecoder(opts, str)
+	out := d.decodeYaml(&node)
+	if len(d.errs) != 0 {
+		return out, d.errs
+	}
+	return out, nil
+}
+
+// The yamlSyntaxError returns err of YAML parser as DecodeError,
+// with the line out of its message.
+func yamlSyntaxError(err error) *DecodeError {
+	e := &DecodeError{Offset: -1, Err: err}
+	if m := reYamlLine.FindStringSubmatch(err.Error()); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+	}
+	return e
+}
+
+var reYamlLine = regexp.MustCompile(`^yaml: line ([0-9]+):`)
+
+// The yamlDecoder keeps state of single Decode call.
+type yamlDecoder struct {
+	opts   DecodeOptions
+	lines  []string     // the source, to keep original text of values
+	path   Elements     // the parents of current elements
+	errs   DecodeErrors // all errors found so far
+	budget *Budget      // the use of limits, but MaxElements
+	stop   bool         // the limit is exceeded
+
+	elements    int                         // the number of elements, including reused ones
+	maxElements int                         // the limit of elements
+	anchors     map[anchorKey]decodedAnchor // the elements of anchors decoded already
+}
+
+// The maxElements bounds the decoding without Limits.MaxElements
+const maxElements = 1 << 20
+
+func newYamlDecoder(opts DecodeOptions, src string) *yamlDecoder {
+	// The elements are counted by decoder, as the reused elements of anchors
+	// are not decoded once again
+	limits := opts.Limits
+	limits.MaxElements = 0
+	d := &yamlDecoder{opts: opts, lines: strings.Split(src, "\n"), budget: tlv.NewBudget(limits)}
+	d.maxElements = opts.Limits.MaxElements
+	if d.maxElements == 0 {
+		d.maxElements = maxElements
+	}
+	d.anchors = map[anchorKey]decodedAnchor{}
+	return d
+}
+
+// The count adds n elements, and fails if those exceed the limit of elements.
+// The negative n is overflow of count, so it exceeds the limit too.
+func (d *yamlDecoder) count(n int) error {
+	if n < 0 || n > d.maxElements-d.elements {
+		return &LimitError{Limit: "elements", Max: d.maxElements}
+	}
+	d.elements += n
+	return nil
+}
+
+// The anchorKey is the anchor node decoded within dictionary
+type anchorKey struct {
+	node *yaml.Node
+	dict uintptr
+}
+
+// The decodedAnchor is the elements of anchor and their count including sub-elements
+type decodedAnchor struct {
+	els   Elements
+	count int
+}
+
+// The decodeAnchor decodes nodes of anchor to out once per dictionary,
+// and appends copy of its elements for the following aliases,
+// so the nested aliases do not decode their anchors exponentially many times.
+// The reused elements are counted by limits anyway.
+func (d *yamlDecoder) decodeAnchor(anchor *yaml.Node, nodes []*yaml.Node, dict Dictionary, out *Elements) {
+	key := anchorKey{node: anchor, dict: reflect.ValueOf(dict).Pointer()}
+	if a, ok := d.anchors[key]; ok {
+		if d.limit(anchor, d.count(a.count)) {
+			return
+		}
+		for _, el := range a.els {
+			if d.limit(anchor, d.budget.Enter()) {
+				return
+			}
+			d.budget.Leave()
+			*out = append(*out, copyElement(el))
+			d.vendor(dict, &(*out)[len(*out)-1])
+		}
+		return
+	}
+
+	first, count := len(*out), d.elements
+	d.decodeContent(nodes, dict, out)
+	a := decodedAnchor{els: make(Elements, 0, len(*out)-first), count: d.elements - count}
+	for _, el := range (*out)[first:] {
+		a.els = append(a.els, copyElement(el))
+	}
+	d.anchors[key] = a
+}
+
+// The copyElement returns el with own Source, as those are modified
+// (i.e. comments of merged elements), but shares the sub-elements.
+func copyElement(el Element) Element {
+	if el.Source != nil {
+		s := *el.Source
+		el.Source = &s
+	}
+	return el
+}
+
+// The limit records err of exceeded limit at node, if any.
+// The exceeded limit stops decoding.
+func (d *yamlDecoder) limit(node *yaml.Node, err error) bool {
+	if err != nil {
+		d.fail(node, nil, err)
+		d.stop = true
+	}
+	return d.stop
+}
+
+// The fail records err as DecodeError at node, within element el
+// (if given) and its parents.
+// The err being DecodeError already is located at nested node,
+// so it gets only the path.
+func (d *yamlDecoder) fail(node *yaml.Node, el *Element, err error) {
+	var e *DecodeError
+	if !errors.As(err, &e) {
+		e = &DecodeError{Offset: -1, Line: node.Line, Column: node.Column, Err: err}
+		if node.Kind == yaml.ScalarNode {
+			e.Value = node.Value
+		}
+	}
+	path := d.path
+	if el != nil {
+		path = append(path[:len(path):len(path)], *el)
+	}
+	for _, p := range path {
+		e.Path = append(e.Path, p.T)
+		e.Names = append(e.Names, p.Name)
 	}
+	d.errs = append(d.errs, e)
+}
 
+// The decodeYaml decodes YAML document node.
+func (d *yamlDecoder) decodeYaml(node *yaml.Node) Elements {
+	if d.opts.Template {
+		node = expandNode(node, d.opts.Vars)
+	}
 	out := Elements{}
-	err := decodeYamlDocument(&node, &out)
-	return out, err
+	d.decodeDocument(node, d.opts.Dictionary, &out)
+	return out
 }
 
-func decodeYamlDocument(node *yaml.Node, out *Elements) error {
+func (d *yamlDecoder) decodeDocument(node *yaml.Node, dict Dictionary, out *Elements) {
 	if node.Kind != yaml.DocumentNode {
-		return errors.WithStack(errNoYamlDocumentNode)
+		d.fail(node, nil, ErrNoYamlDocumentNode)
+		return
 	}
 
-	err := decodeYamlContent(node.Content, out)
-	return err
+	d.decodeContent(node.Content, dict, out)
 }
 
-func decodeYamlContent(nodes []*yaml.Node, out *Elements) error {
-	var err error
+func (d *yamlDecoder) decodeContent(nodes []*yaml.Node, dict Dictionary, out *Elements) {
 	index := 0
-	for index < len(nodes) {
-		index, err = decodeYamlNode(nodes, index, out)
-		if err != nil {
-			return err
-		}
+	for index < len(nodes) && !d.stop {
+		index = d.decodeNode(nodes, index, dict, out)
 	}
-
-	return nil
 }
 
-func decodeYamlNode(nodes []*yaml.Node, index int, out *Elements) (int, error) {
+func (d *yamlDecoder) decodeNode(nodes []*yaml.Node, index int, dict Dictionary, out *Elements) int {
 	n := nodes[index]
 	if n == nil {
-		return index + 1, nil
+		return index + 1
 	}
 
 	switch n.Kind {
+	case yaml.AliasNode:
+		if d.limit(n, d.count(1)) || d.limit(n, d.budget.Enter()) {
+			return index + 1
+		}
+		d.budget.Leave()
+		d.decodeAnchor(n.Alias, []*yaml.Node{n.Alias}, dict, out)
+		return index + 1
 	case yaml.MappingNode:
-		return decodeYamlNodeMapping(nodes, index, out)
+		d.decodeMapping(n, dict, out)
+		return index + 1
+	case yaml.SequenceNode:
+		// List of TLVs, as Stringify outputs more than one element
+		d.decodeContent(n.Content, dict, out)
+		return index + 1
 	case yaml.ScalarNode:
-		// TLV Type
-		if err := decodeYamlAppendElement(nodes[index+0], out); err != nil {
-			return index, err
-		}
-		// TLV Value
-		if err := decodeYamlSetElementValue(nodes[index+1], out); err != nil {
-			return index, err
+		if index+1 >= len(nodes) {
+			d.fail(n, nil, ErrYamlMappingNodeWrongContentSize)
+			return index + 1
 		}
-		return index + 2, nil
+		d.decodeElement(nodes[index+0], nodes[index+1], dict, out)
+		return index + 2
 	}
 
-	return index, errors.WithStack(errUnsupportedYamlNodeKind)
+	d.fail(n, nil, ErrUnsupportedYamlNodeKind)
+	return index + 1
 }
 
-func decodeYamlNodeMapping(nodes []*yaml.Node, index int, out *Elements) (int, error) {
-	n := nodes[index]
-
+func (d *yamlDecoder) decodeMapping(n *yaml.Node, dict Dictionary, out *Elements) {
 	if len(n.Content)%2 != 0 {
-		return index, errors.WithStack(errYamlMappingNodeWrongContentSize)
+		d.fail(n, nil, ErrYamlMappingNodeWrongContentSize)
+		return
 	}
 
+	first := len(*out)
 	for i := 0; i < len(n.Content); i += 2 {
-		// TLV Type
-		if err := decodeYamlAppendElement(n.Content[i+0], out); err != nil {
-			return index, err
+		d.decodeElement(n.Content[i+0], n.Content[i+1], dict, out)
+	}
+
+	// The comments of mapping, i.e. list item, belong to its elements
+	if len(*out) > first {
+		if src := (*out)[first].Source; src != nil && n.HeadComment != "" {
+			src.HeadComment = joinComments(n.HeadComment, src.HeadComment)
 		}
-		// TLV Value
-		if err := decodeYamlSetElementValue(n.Content[i+1], out); err != nil {
-			return index, err
+		if src := (*out)[len(*out)-1].Source; src != nil && n.FootComment != "" {
+			src.FootComment = joinComments(src.FootComment, n.FootComment)
 		}
 	}
+}
 
-	return index + 1, nil
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+// The decodeElement appends element of key node and sets its value out of value node.
+// The element is not appended in case of error.
+func (d *yamlDecoder) decodeElement(key, value *yaml.Node, dict Dictionary, out *Elements) {
+	// The merge key "<<: *anchor" inserts TLVs of anchor
+	if key.Tag == "!!merge" {
+		first := len(*out)
+		d.decodeContent([]*yaml.Node{value}, dict, out)
+		// The comments stay with anchor
+		for _, el := range (*out)[first:] {
+			if el.Source != nil {
+				el.Source.HeadComment, el.Source.LineComment, el.Source.FootComment = "", "", ""
+			}
+		}
+		return
+	}
+	if d.opts.Template && d.decodeRepeat(key, value, dict, out) {
+		return
+	}
+	alias := value.Kind == yaml.AliasNode
+	if alias {
+		value = value.Alias
+	}
+	if d.limit(key, d.count(1)) || d.limit(key, d.budget.Enter()) {
+		return
+	}
+	defer d.budget.Leave()
+
+	// TLV Type
+	if err := d.appendElement(key, dict, out); err != nil {
+		d.fail(key, nil, err)
+		return
+	}
+	// TLV Value
+	el := &(*out)[len(*out)-1]
+	if err := d.setElementValue(value, out); err != nil {
+		d.fail(value, el, err)
+		*out = (*out)[:len(*out)-1]
+		d.stop = d.stop || errors.Is(err, ErrLimitExceeded)
+		return
+	}
+	el.Source = d.source(key, value, alias, el)
+	d.vendor(dict, el)
+}
+
+// The vendor adds the dictionary of vendor to dict of vendor-specific container,
+// when el is its vendor ID, so the following elements are decoded with it.
+func (d *yamlDecoder) vendor(dict Dictionary, el *Element) {
+	if len(d.path) == 0 {
+		return
+	}
+	parent := d.path[len(d.path)-1].Def
+	if parent == nil || parent.VendorID == 0 || el.T != parent.VendorID {
+		return
+	}
+	if id, ok := vendorOf(el.V); ok {
+		if sub, ok := parent.vendorSub(id); ok {
+			mergeDictionary(dict, sub)
+		}
+	}
 }
 
 var reKey = regexp.MustCompile(`(.*)\(([0-9]*)\).*`)
 
-func decodeYamlAppendElement(node *yaml.Node, out *Elements) error {
-	strict := false
+func (d *yamlDecoder) appendElement(node *yaml.Node, dict Dictionary, out *Elements) error {
+	if d.opts.Template {
+		if err := checkVars(node); err != nil {
+			return err
+		}
+	}
 	name := ""
 	//
 	// TLV Type
@@ -105,10 +390,24 @@ func decodeYamlAppendElement(node *yaml.Node, out *Elements) error {
 	if err != nil {
 		// try "Text(integer)"
 		m := reKey.FindAllStringSubmatch(s, -1)
-		if (len(m) != 1 || len(m[0]) != 3) && strict {
-			return errors.WithStack(errUnsupportedKey)
-		}
-		if (len(m) != 1 || len(m[0]) != 3) && !strict {
+		switch {
+		case len(m) == 1 && len(m[0]) == 3:
+			name = m[0][1]
+			s = m[0][2]
+			if s == "" {
+				return ErrUnsupportedKey
+			}
+			t, err = strconv.ParseUint(s, 0, 64)
+			if err != nil {
+				return err
+			}
+		case d.opts.ResolveNames && dict.ByName(s) != nil:
+			// The bare name known to dictionary
+			name = s
+			t = uint64(dict.ByName(s).Type)
+		case d.opts.Strict:
+			return ErrUnsupportedKey
+		default:
 			// This is non strict decode
 			// and type name is a string
 			// but not unexpected format
@@ -118,73 +417,153 @@ func decodeYamlAppendElement(node *yaml.Node, out *Elements) error {
 			name = s
 			t = 0x100000
 		}
-		if len(m) == 1 && len(m[0]) == 3 {
-			name = m[0][1]
-			s = m[0][2]
-			if s == "" {
-				return errors.WithStack(errUnsupportedKey)
-			}
-			t, err = strconv.ParseUint(s, 0, 64)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-		}
+	}
+	if d.opts.Strict && t > 255 {
+		return ErrTypeOutOfRange
 	}
 
-	*out = append(*out, Element{name, int(t), nil, nil})
+	el := Element{Name: name, T: int(t)}
+	if def, ok := dict[el.T]; ok {
+		el.Def = def
+		if el.Name == "" {
+			el.Name = def.Name
+		}
+	}
+	*out = append(*out, el)
 	return nil
 }
 
-func decodeYamlSetElementValue(node *yaml.Node, out *Elements) error {
+func (d *yamlDecoder) setElementValue(node *yaml.Node, out *Elements) error {
+	el := &(*out)[len(*out)-1]
+	var sub Dictionary
+	if el.Def != nil {
+		sub = el.Def.Sub
+	}
+
+	// The values are bounded by limits
+	if node.Kind == yaml.SequenceNode && node.Style == yaml.FlowStyle {
+		if err := d.budget.SliceLen(len(node.Content)); err != nil {
+			return err
+		}
+	}
+	if node.Kind == yaml.ScalarNode {
+		if err := d.budget.StringLen(len(node.Value)); err != nil {
+			return err
+		}
+	}
+
 	switch {
 	case (node.Kind == yaml.MappingNode) || (node.Kind == yaml.SequenceNode && node.Style == 0): // nested TLVs
 		values := Elements{}
-		err := decodeYamlContent(node.Content, &values)
+		if el.Def != nil && el.Def.VendorID != 0 {
+			// The dictionary of vendor is added, when vendor ID is decoded
+			sub = make(Dictionary, len(el.Def.Sub))
+			mergeDictionary(sub, el.Def.Sub)
+		}
+		d.path = append(d.path, *el)
+		if node.Anchor != "" {
+			d.decodeAnchor(node, node.Content, sub, &values)
+		} else {
+			d.decodeContent(node.Content, sub, &values)
+		}
+		d.path = d.path[:len(d.path)-1]
+		el.Sub = values
+
+	case node.Kind == yaml.SequenceNode && node.Style == yaml.FlowStyle && isYamlFlags(node, el.Def): // list of flags
+		value, err := decodeYamlFlags(node, el.Def)
 		if err != nil {
 			return err
 		}
-		(*out)[len(*out)-1].Sub = values
+		el.V = value
 
 	case node.Kind == yaml.SequenceNode && node.Style == yaml.FlowStyle: // array of V
-		value, err := decodeYamlArray(node)
+		value, err := d.decodeArray(node)
 		if err != nil {
 			return err
 		}
-		(*out)[len(*out)-1].V = value
+		el.V = value
 
 	case node.Kind == yaml.ScalarNode:
-		value, err := decodeYamlValue(node)
+		value, err := d.decodeValue(node, el.Def)
 		if err != nil {
 			return err
 		}
-		(*out)[len(*out)-1].V = value
+		el.V = value
 
 	default:
-		return errors.WithStack(errUnsupportedYamlNodeKind)
+		return ErrUnsupportedYamlNodeKind
 	}
 
 	return nil
 }
 
-func decodeYamlArray(node *yaml.Node) (T8L16, error) {
+func (d *yamlDecoder) decodeArray(node *yaml.Node) (T8L16, error) {
 	v := make(T8L16, 0, 16)
 	for _, n := range node.Content {
-		a, err := decodeYamlValue(n)
+		a, err := d.decodeValue(n, nil)
 		if err != nil {
-			return nil, err
+			// Locate the item, not the whole array
+			return nil, &DecodeError{Offset: -1, Line: n.Line, Column: n.Column, Value: n.Value, Err: err}
 		}
 		v = append(v, a...)
 	}
 	return v, nil
 }
 
-func decodeYamlValue(node *yaml.Node) (T8L16, error) {
+// The isYamlFlags checks if node is list of flags of bitmask value.
+// It is, when the definition has FlagSet and any item is not a number.
+func isYamlFlags(node *yaml.Node, def *Definition) bool {
+	if def == nil || def.Flags == nil {
+		return false
+	}
+	for _, n := range node.Content {
+		if n.Kind != yaml.ScalarNode {
+			return false
+		}
+		if _, err := strconv.ParseUint(n.Value, 0, 8); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeYamlFlags(node *yaml.Node, def *Definition) (T8L16, error) {
+	flags := make([]string, 0, len(node.Content))
+	for _, n := range node.Content {
+		flags = append(flags, n.Value)
+	}
+	return def.Flags.Parse(flags, def.Size)
+}
+
+// The decodeValue decodes scalar node, which must have no parameters left
+func (d *yamlDecoder) decodeValue(node *yaml.Node, def *Definition) (T8L16, error) {
+	if d.opts.Template {
+		if err := checkVars(node); err != nil {
+			return nil, err
+		}
+	}
+	return decodeYamlValue(node, def)
+}
+
+func decodeYamlValue(node *yaml.Node, def *Definition) (T8L16, error) {
 	s := node.Value
 
 	if node.Style == yaml.DoubleQuotedStyle {
 		return T8L16(s), nil
 	}
 
+	// try name of enumerated value
+	if def != nil {
+		if u, ok := def.Enum.Value(s); ok {
+			return putUint(u, def.Size)
+		}
+	}
+
+	// try typed scalar
+	if v, ok, err := decodeScalar(s); ok {
+		return v, err
+	}
+
 	// try to guess type
 	if mac, err := net.ParseMAC(s); err == nil {
 		return T8L16(mac), nil
@@ -195,11 +574,6 @@ func decodeYamlValue(node *yaml.Node) (T8L16, error) {
 		}
 		return T8L16(ip), nil
 	}
-	if u, err := parseUint16(s); err == nil {
-		v := T8L16{0, 0}
-		binary.NetworkByteOrder.PutUint16(v, u)
-		return v, nil
-	}
 	if s == "null" {
 		return T8L16{}, nil
 	}
@@ -217,43 +591,61 @@ func decodeYamlValue(node *yaml.Node) (T8L16, error) {
 		// try "Text(integer)"
 		m := reKey.FindAllStringSubmatch(s, -1)
 		if (len(m) != 1 || len(m[0]) != 3) && strict {
-			return nil, errors.WithStack(errUnsupportedValue)
+			return nil, ErrUnsupportedValue
 		}
 		if len(m) == 1 && len(m[0]) == 3 {
+			name := m[0][1]
 			s = m[0][2]
 			if s == "" {
-				return nil, errors.WithStack(errUnsupportedValue)
+				return nil, ErrUnsupportedValue
 			}
 			t, err := strconv.ParseUint(s, 0, 64)
 			if err != nil {
-				return nil, errors.WithStack(err)
-			}
-			v := T8L16{0, 0, 0, 0, 0, 0, 0, 0}
-			binary.NetworkByteOrder.PutUint64(v, t)
-			for v[0] == 0 && len(v) > 1 {
-				v = v[1:]
+				return nil, err
 			}
-			for len(v) != 1 && len(v) != 2 && len(v) != 4 && len(v) != 8 {
-				v = append(v, 0)
+			size := 0
+			if def != nil {
+				size = def.Size
+				if known, ok := def.Enum[t]; ok && known != name {
+					return nil, fmt.Errorf("%s: %w", s, ErrEnumMismatch)
+				}
 			}
-			return v, nil
+			return putUint(t, size)
 		}
 	}
 
 	return T8L16{byte(n)}, nil
 }
 
+// The putUint returns u in network byte order of size octets.
+// If size is 0, the value is the smallest of 1, 2, 4 or 8 octets fitting u.
+func putUint(u uint64, size int) (T8L16, error) {
+	if size == 0 {
+		size = 1
+		for size < 8 && u>>(8*uint(size)) != 0 {
+			size *= 2
+		}
+	}
+	if size > 8 || (size < 8 && u>>(8*uint(size)) != 0) {
+		return nil, ErrValueOutOfRange
+	}
+
+	v := make(T8L16, 8)
+	binary.NetworkByteOrder.PutUint64(v, u)
+	return v[8-size:], nil
+}
+
 // The parseSliceOfBytes function parses string representation of byte slice
 func parseSliceOfBytes(s string) ([]byte, error) {
 	// Check preconditions
 	if len(s) == 0 {
-		return nil, errors.WithStack(errCanNotParseSliceOfBytes)
+		return nil, ErrCanNotParseSliceOfBytes
 	}
 	if s[0] != '[' {
-		return nil, errors.WithStack(errCanNotParseSliceOfBytes)
+		return nil, ErrCanNotParseSliceOfBytes
 	}
 	if s[len(s)-1] != ']' {
-		return nil, errors.WithStack(errCanNotParseSliceOfBytes)
+		return nil, ErrCanNotParseSliceOfBytes
 	}
 
 	//
@@ -265,24 +657,10 @@ func parseSliceOfBytes(s string) ([]byte, error) {
 	for _, s := range a {
 		n, err := strconv.ParseUint(s, 0, 8)
 		if err != nil {
-			return res, errors.WithStack(err)
+			return res, err
 		}
 		res = append(res, byte(n))
 	}
 
 	return res, nil
 }
-
-// The parseUint16 function parses uint16 string in form of "uint16(1234)"
-func parseUint16(s string) (uint16, error) {
-	if len(s) < 8 {
-		return 0, errors.WithStack(errStringTooShort)
-	}
-	if s[:7] != "uint16(" && s[len(s)-1] != ')' {
-		return 0, errors.WithStack(errWrongFormat)
-	}
-
-	s = s[7 : len(s)-1]
-	n, err := strconv.ParseUint(s, 0, 16)
-	return uint16(n), errors.WithStack(err)
-}
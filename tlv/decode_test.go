@@ -35,27 +35,30 @@ IRA(1):
 
 	str, err := Stringify(msg)
 	assert.NoError(err)
-	expStr := `9:
-    - 10: [0,1]
-    - 11: [7]
-    - 60:
-        - 2: [17,34,51,68,85,102]
-        - 3: [47,208,1,0,0,0,0,0,0,0,0,0,0,0,18,52]
-        - 4: [0]
-        - 5: [103,111,45,99,99,97,112]
-        - 6: [17,139]
-        - 7: [2]
-        - 8: [0]
-        - 10: [0,16]
-        - 201: [172,30,20,10]
-        - 202: null
+	expStr := `# This is example of R-PHY GCP AllocateWrite message
+IRA(1): 
+    Sequence(9): 
+        - SequenceNumber(10): [0,1]
+        - Operation(11): [7]
+        - CcapCoreIdentification(60): 
+            - CoreId(2): 11:22:33:44:55:66 # MAC
+            - CoreIpAddress(3): 2fd0:100::1234 # IPv6 address
+            - IsPrincipal(4): [0]
+            - CoreName(5): "go-ccap"
+            - VendorId(6): uint16(4491)
+            - CoreMode(7): [2]
+            - InitialConfigurationComplete(8): false
+            - CoreFunction(10): [0,16]
+            # following is just unrealistic values for this test
+            - 201: 172.30.20.10 # IPv4
+            - 202: null
 `
 	assert.Equal(expStr, str)
 
 	t8l16, err := Marshal(msg)
 	assert.NoError(err)
 
-	expBin := tlv.T8L16(tlv.T8L16{0x9, 0x0, 0x52, 0xa, 0x0, 0x2, 0x0, 0x1, 0xb, 0x0, 0x1, 0x7, 0x3c, 0x0, 0x46, 0x2, 0x0, 0x6, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x3, 0x0, 0x10, 0x2f, 0xd0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x12, 0x34, 0x4, 0x0, 0x1, 0x0, 0x5, 0x0, 0x7, 0x67, 0x6f, 0x2d, 0x63, 0x63, 0x61, 0x70, 0x6, 0x0, 0x2, 0x11, 0x8b, 0x7, 0x0, 0x1, 0x2, 0x8, 0x0, 0x1, 0x0, 0xa, 0x0, 0x2, 0x0, 0x10, 0xc9, 0x0, 0x4, 0xac, 0x1e, 0x14, 0xa, 0xca, 0x0, 0x0})
+	expBin := tlv.T8L16(tlv.T8L16{0x1, 0x0, 0x55, 0x9, 0x0, 0x52, 0xa, 0x0, 0x2, 0x0, 0x1, 0xb, 0x0, 0x1, 0x7, 0x3c, 0x0, 0x46, 0x2, 0x0, 0x6, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x3, 0x0, 0x10, 0x2f, 0xd0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x12, 0x34, 0x4, 0x0, 0x1, 0x0, 0x5, 0x0, 0x7, 0x67, 0x6f, 0x2d, 0x63, 0x63, 0x61, 0x70, 0x6, 0x0, 0x2, 0x11, 0x8b, 0x7, 0x0, 0x1, 0x2, 0x8, 0x0, 0x1, 0x0, 0xa, 0x0, 0x2, 0x0, 0x10, 0xc9, 0x0, 0x4, 0xac, 0x1e, 0x14, 0xa, 0xca, 0x0, 0x0})
 	assert.Equal(expBin, t8l16)
 }
 
@@ -135,18 +138,60 @@ func TestDecodeAssumption(t *testing.T) {
 	a, err = parseSliceOfBytes("[256]")
 	assert.Error(err)
 
-	//
-	var u uint16
-	u, err = parseUint16("uint16(1234)")
+}
+
+func TestDecodeStrict(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `
+Sequence(9):
+    SequnceNumber: [0, 1]
+    Operation(11): 7
+`
+	// The unknown key is kept out of wire message by default
+	out, err := Decode(yaml)
 	assert.NoError(err)
-	assert.Equal(uint16(1234), u)
-	u, err = parseUint16(" uint16(1234)")
-	assert.Error(err)
-	u, err = parseUint16("uint16(1234) ")
-	assert.Error(err)
-	u, err = parseUint16(" uint16(1234) ")
-	assert.Error(err)
-	u, err = parseUint16("uint16(0x1234)")
+	bin, err := Marshal(out)
 	assert.NoError(err)
-	assert.Equal(uint16(0x1234), u)
+	assert.Equal(T8L16{9, 0, 4, 11, 0, 1, 7}, bin)
+
+	_, err = MarshalWithOptions(out, MarshalOptions{Strict: true})
+	assert.ErrorIs(err, ErrTypeOutOfRange)
+	var e *DecodeError
+	if assert.ErrorAs(err, &e) {
+		assert.Equal([]string{"Sequence", "SequnceNumber"}, e.Names)
+	}
+
+	_, err = DecodeWithOptions(yaml, DecodeOptions{Strict: true})
+	assert.ErrorIs(err, ErrUnsupportedKey)
+	if assert.ErrorAs(err, &e) {
+		assert.Equal([]int{9}, e.Path)
+		assert.Equal("SequnceNumber", e.Value)
+	}
+
+	_, err = DecodeWithOptions("Sequence(256): 1\n", DecodeOptions{Strict: true})
+	assert.ErrorIs(err, ErrTypeOutOfRange)
+}
+
+func TestDecodeResolveNames(t *testing.T) {
+	assert := assert.New(t)
+
+	dict, err := ParseDictionary([]byte(testDictionary))
+	assert.NoError(err)
+
+	yaml := `
+Sequence:
+    SequenceNumber: [0, 1]
+    CcapCoreIdentification:
+        CoreFunction: [Principal]
+`
+	opts := DecodeOptions{Dictionary: dict, ResolveNames: true, Strict: true}
+	out, err := DecodeWithOptions(yaml, opts)
+	assert.NoError(err)
+	bin, err := MarshalWithOptions(out, MarshalOptions{Strict: true})
+	assert.NoError(err)
+	assert.Equal(T8L16{9, 0, 13, 10, 0, 2, 0, 1, 60, 0, 5, 10, 0, 2, 0, 1}, bin)
+
+	_, err = DecodeWithOptions("Sequence:\n    SequnceNumber: [0, 1]\n", opts)
+	assert.ErrorIs(err, ErrUnsupportedKey)
 }
@@ -0,0 +1,110 @@
+package tlv
+
+import (
+	"fmt"
+	"sort"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Dictionary describes TLV types of single nesting level.
+// The key is the TLV Type.
+type Dictionary map[int]*Definition
+
+// Definition describes single TLV type.
+type Definition struct {
+	Name  string     `yaml:"name"`
+	Type  int        `yaml:"type"`
+	Size  int        `yaml:"size,omitempty"`  // fixed size of value in octets, 0 if not fixed
+	Flags FlagSet    `yaml:"flags,omitempty"` // names of bits of bitmask value
+	Enum  Enum       `yaml:"enum,omitempty"`  // names of enumerated values
+	Sub   Dictionary `yaml:"sub,omitempty"`   // sub-elements of container
+
+	// VendorID is type of sub-element with vendor ID of vendor-specific container,
+	// which content is described by Sub and the dictionary of vendor (see RegisterVendor).
+	VendorID int `yaml:"vendorId,omitempty"`
+
+	// GoType is Go type of value for generated code, i.e. "string" or "net.IP".
+	// By default it is unsigned integer of size, or []byte.
+	GoType string `yaml:"goType,omitempty"`
+
+	// The following is used by validation
+	Required bool    `yaml:"required,omitempty"` // must be present within parent
+	Repeated bool    `yaml:"repeated,omitempty"` // may be present more than once within parent
+	MinLen   int     `yaml:"minLen,omitempty"`   // minimal length of value
+	MaxLen   int     `yaml:"maxLen,omitempty"`   // maximal length of value, 0 if not limited
+	Min      *uint64 `yaml:"min,omitempty"`      // minimal numeric value
+	Max      *uint64 `yaml:"max,omitempty"`      // maximal numeric value
+}
+
+// ParseDictionary parses YAML list of definitions into Dictionary.
+// Each definition has name, type and optional size, flags, enum,
+// sub-elements' list and validation constraints (see README).
+func ParseDictionary(data []byte) (Dictionary, error) {
+	d := Dictionary{}
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// UnmarshalYAML decodes list of definitions
+func (d *Dictionary) UnmarshalYAML(node *yaml.Node) error {
+	var list []*Definition
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+
+	if *d == nil {
+		*d = Dictionary{}
+	}
+	for _, def := range list {
+		if def == nil || def.Name == "" || def.Type < 0 || def.Type > 255 {
+			return ErrBadDefinition
+		}
+		if _, ok := (*d)[def.Type]; ok {
+			return fmt.Errorf("%s(%d): %w", def.Name, def.Type, ErrDuplicateDefinition)
+		}
+		(*d)[def.Type] = def
+	}
+	return nil
+}
+
+// ByName returns definition by name or nil
+func (d Dictionary) ByName(name string) *Definition {
+	for _, def := range d {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// The types returns sorted TLV types of dictionary
+func (d Dictionary) types() []int {
+	types := make([]int, 0, len(d))
+	for t := range d {
+		types = append(types, t)
+	}
+	sort.Ints(types)
+	return types
+}
+
+// Annotate sets definition and missing name of all elements known by dictionary
+func (d Dictionary) Annotate(data Elements) {
+	for i := range data {
+		el := &data[i]
+		def, ok := d[el.T]
+		if !ok {
+			continue
+		}
+
+		el.Def = def
+		if el.Name == "" {
+			el.Name = def.Name
+		}
+		if el.Sub != nil {
+			def.subOfElements(el.Sub).Annotate(el.Sub)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDictionary = `
+- name: Sequence
+  type: 9
+  sub:
+    - name: SequenceNumber
+      type: 10
+      size: 2
+    - name: CcapCoreIdentification
+      type: 60
+      sub:
+        - name: CoreFunction
+          type: 10
+          size: 2
+          flags: {0: Principal, 4: Auxiliary, 12: Video}
+`
+
+func TestParseDictionary(t *testing.T) {
+	assert := assert.New(t)
+
+	dict, err := ParseDictionary([]byte(testDictionary))
+	assert.NoError(err)
+	if assert.Contains(dict, 9) {
+		assert.Equal("Sequence", dict[9].Name)
+		assert.Equal(dict[9], dict.ByName("Sequence"))
+		assert.Equal(2, dict[9].Sub[10].Size)
+		assert.Equal(FlagSet{0: "Principal", 4: "Auxiliary", 12: "Video"}, dict[9].Sub[60].Sub[10].Flags)
+	}
+	assert.Nil(dict.ByName("Unknown"))
+
+	_, err = ParseDictionary([]byte("- {name: A, type: 1}\n- {name: B, type: 1}\n"))
+	assert.Error(err)
+	_, err = ParseDictionary([]byte("- {name: A, type: 256}\n"))
+	assert.Error(err)
+	_, err = ParseDictionary([]byte("- {type: 1}\n"))
+	assert.Error(err)
+}
+
+func TestFlagSet(t *testing.T) {
+	assert := assert.New(t)
+	f := FlagSet{0: "A", 4: "B", 12: "C"}
+
+	s, ok := f.Format(T8L16{0x10, 0x01})
+	assert.True(ok)
+	assert.Equal("[A, C]", s)
+	_, ok = f.Format(T8L16{0x00, 0x02})
+	assert.False(ok)
+	_, ok = f.Format(T8L16{0x00, 0x00})
+	assert.False(ok)
+
+	v, err := f.Parse([]string{"A", "C"}, 2)
+	assert.NoError(err)
+	assert.Equal(T8L16{0x10, 0x01}, v)
+	v, err = f.Parse([]string{"B", "1"}, 0)
+	assert.NoError(err)
+	assert.Equal(T8L16{0x12}, v)
+	v, err = f.Parse([]string{"C"}, 0)
+	assert.NoError(err)
+	assert.Equal(T8L16{0x10, 0x00}, v)
+	_, err = f.Parse([]string{"D"}, 2)
+	assert.Error(err)
+	_, err = f.Parse([]string{"C"}, 1)
+	assert.Error(err)
+}
+
+func TestDecodeFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	dict, err := ParseDictionary([]byte(testDictionary))
+	assert.NoError(err)
+
+	yaml := `Sequence(9):
+    SequenceNumber(10): [0,1]
+    60:
+        - 10: [Principal, Video]
+`
+	msg, err := DecodeWithOptions(yaml, DecodeOptions{Dictionary: dict})
+	assert.NoError(err)
+
+	bin, err := Marshal(msg)
+	assert.NoError(err)
+	assert.Equal(T8L16{9, 0, 13, 10, 0, 2, 0, 1, 60, 0, 5, 10, 0, 2, 0x10, 0x01}, bin)
+
+	str, err := Stringify(msg)
+	assert.NoError(err)
+	assert.Equal("Sequence(9): \n    - SequenceNumber(10): [0,1]\n    - CcapCoreIdentification(60): \n        CoreFunction(10): [Principal, Video]\n", str)
+
+	// Generic TLV structure is annotated by dictionary
+	out := Elements{}
+	assert.NoError(UnmarshalT8L16(bin, &out))
+	dict.Annotate(out)
+	str2, err := Stringify(out)
+	assert.NoError(err)
+	assert.Equal(str, str2)
+
+	// Without dictionary the flags are unsupported values
+	_, err = Decode(yaml)
+	assert.Error(err)
+}
+
+func TestDecodeEnum(t *testing.T) {
+	assert := assert.New(t)
+
+	dict := Dictionary{
+		7: {Name: "CoreMode", Type: 7, Size: 1, Enum: Enum{1: "Active", 2: "Backup"}},
+		8: {Name: "Level", Type: 8, Size: 2, Enum: Enum{0x100: "High"}},
+	}
+	yaml := `- CoreMode(7): Backup(2)
+- CoreMode(7): Active
+- 7: [9]
+- Level(8): High
+- Level(8): Low(1)
+`
+	msg, err := DecodeWithOptions(yaml, DecodeOptions{Dictionary: dict})
+	assert.NoError(err)
+
+	bin, err := Marshal(msg)
+	assert.NoError(err)
+	assert.Equal(T8L16{7, 0, 1, 2, 7, 0, 1, 1, 7, 0, 1, 9, 8, 0, 2, 1, 0, 8, 0, 2, 0, 1}, bin)
+
+	// The decoded values keep their source text
+	str, err := Stringify(msg)
+	assert.NoError(err)
+	assert.Equal(`- CoreMode(7): Backup(2)
+- CoreMode(7): Active
+- CoreMode(7): [9]
+- Level(8): High
+- Level(8): Low(1)
+`, str)
+
+	// The values without source are formatted by the enum
+	for i := range msg {
+		msg[i].Source = nil
+	}
+	str, err = Stringify(msg)
+	assert.NoError(err)
+	assert.Equal(`- CoreMode(7): Backup(2)
+- CoreMode(7): Active(1)
+- CoreMode(7): [9]
+- Level(8): High(256)
+- Level(8): [0,1]
+`, str)
+
+	again, err := DecodeWithOptions(str, DecodeOptions{Dictionary: dict})
+	assert.NoError(err)
+	bin2, err := Marshal(again)
+	assert.NoError(err)
+	assert.Equal(bin, bin2)
+
+	_, err = DecodeWithOptions("CoreMode(7): Active(2)\n", DecodeOptions{Dictionary: dict})
+	assert.Error(err)
+	_, err = DecodeWithOptions("CoreMode(7): Passive\n", DecodeOptions{Dictionary: dict})
+	assert.Error(err)
+	_, err = DecodeWithOptions("CoreMode(7): Backup(256)\n", DecodeOptions{Dictionary: dict})
+	assert.Error(err)
+}
+
+func TestDecodeTextValue(t *testing.T) {
+	assert := assert.New(t)
+
+	// The value without definition is the smallest of 1, 2, 4 or 8 octets
+	msg, err := Decode("- 1: Any(2)\n- 2: Any(66051)\n")
+	assert.NoError(err)
+	bin, err := Marshal(msg)
+	assert.NoError(err)
+	assert.Equal(T8L16{1, 0, 1, 2, 2, 0, 4, 0, 1, 2, 3}, bin)
+}
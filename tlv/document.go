@@ -0,0 +1,158 @@
+package tlv
+
+// This file has decoding and stringifying of YAML streams of TLV messages.
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Document is single document of YAML stream with TLV message and its metadata
+type Document struct {
+	Comment  string            // head comment of document, i.e. "# REX request"
+	Meta     map[string]string // document level scalars, which are not TLVs, i.e. "direction: request"
+	Elements Elements          // the TLV message
+}
+
+// DecodeAll decodes all documents of YAML stream separated by "---".
+// The document level keys, which are neither number nor "Text(N)",
+// and have scalar value (i.e. "direction: request" or "delay: 100")
+// are kept in Meta of document instead of being decoded as TLVs.
+// The keys of metadata may be declared by DecodeOptions.MetaKeys,
+// which is required in strict mode.
+func DecodeAll(r io.Reader) ([]Document, error) {
+	return DecodeAllWithOptions(r, DecodeOptions{})
+}
+
+// DecodeAllWithOptions is the DecodeAll controlled by opts.
+// The errors of all documents are returned as DecodeErrors,
+// with lines counted from the beginning of stream.
+func DecodeAllWithOptions(r io.Reader, opts DecodeOptions) ([]Document, error) {
+	// The stream is read up to limit, and one byte more to detect it is exceeded
+	max := opts.Limits.MaxBytes
+	if max > 0 {
+		r = io.LimitReader(r, int64(max)+1)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if max > 0 && len(b) > max {
+		return nil, DecodeErrors{{Offset: -1, Err: tlv.NewBudget(opts.Limits).Bytes(len(b))}}
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	d := newYamlDecoder(opts, string(b))
+	docs := []Document{}
+	for !d.stop {
+		node := &yaml.Node{}
+		err := dec.Decode(node)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			d.errs = append(d.errs, yamlSyntaxError(err))
+			break
+		}
+
+		doc := Document{Comment: node.HeadComment}
+		d.splitMeta(node, &doc)
+		doc.Elements = d.decodeYaml(node)
+		docs = append(docs, doc)
+	}
+
+	if len(d.errs) != 0 {
+		return docs, d.errs
+	}
+	return docs, nil
+}
+
+// The splitMeta moves metadata out of document node to doc.
+func (d *yamlDecoder) splitMeta(node *yaml.Node, doc *Document) {
+	if node.Kind != yaml.DocumentNode || len(node.Content) != 1 || node.Content[0].Kind != yaml.MappingNode {
+		return
+	}
+
+	m := node.Content[0]
+	content := make([]*yaml.Node, 0, len(m.Content))
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, value := m.Content[i], m.Content[i+1]
+		if !d.isMeta(key, value) {
+			content = append(content, key, value)
+			continue
+		}
+
+		if doc.Meta == nil {
+			doc.Meta = map[string]string{}
+			if doc.Comment == "" && i == 0 {
+				doc.Comment = key.HeadComment
+			}
+		}
+		doc.Meta[key.Value] = value.Value
+	}
+	m.Content = content
+}
+
+// The isMeta checks if key and value are document metadata rather than TLV
+func (d *yamlDecoder) isMeta(key, value *yaml.Node) bool {
+	if key.Kind != yaml.ScalarNode || value.Kind != yaml.ScalarNode || key.Tag == "!!merge" {
+		return false
+	}
+	s := key.Value
+	if d.opts.MetaKeys != nil || d.opts.Strict {
+		return slices.Contains(d.opts.MetaKeys, s)
+	}
+	if _, err := strconv.ParseUint(s, 0, 64); err == nil {
+		return false
+	}
+	if reKey.MatchString(s) || reRepeat.MatchString(s) {
+		return false
+	}
+	if d.opts.ResolveNames && d.opts.Dictionary.ByName(s) != nil {
+		return false
+	}
+	return true
+}
+
+// StringifyAll stringifies documents into YAML stream, which DecodeAll decodes back.
+// Each document has its comment, then metadata, then TLVs.
+func StringifyAll(docs []Document) (string, error) {
+	var buf bytes.Buffer
+	for i, doc := range docs {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		if doc.Comment != "" {
+			buf.WriteString(strings.TrimSuffix(doc.Comment, "\n"))
+			buf.WriteString("\n")
+		}
+
+		keys := make([]string, 0, len(doc.Meta))
+		for k := range doc.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			// The yaml.v3 quotes the scalars when needed
+			b, err := yaml.Marshal(map[string]string{k: doc.Meta[k]})
+			if err != nil {
+				return buf.String(), err
+			}
+			buf.Write(b)
+		}
+
+		// The TLVs are keys of the same mapping as metadata
+		for _, el := range doc.Elements {
+			if err := stringify(Elements{el}, &buf, 0); err != nil {
+				return buf.String(), err
+			}
+		}
+	}
+	return buf.String(), nil
+}
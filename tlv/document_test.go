@@ -0,0 +1,85 @@
+package tlv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConversation = `# REX request
+direction: request
+REX(2):
+    Sequence(9):
+        SequenceNumber(10): uint16(1)
+        Operation(11): 1
+---
+# REX response
+direction: response
+delay: 100
+REX(2):
+    Sequence(9):
+        SequenceNumber(10): uint16(1)
+        Operation(11): 2
+---
+Notify(3): 1
+`
+
+func TestDecodeAll(t *testing.T) {
+	assert := assert.New(t)
+
+	docs, err := DecodeAll(strings.NewReader(testConversation))
+	assert.NoError(err)
+	if !assert.Len(docs, 3) {
+		return
+	}
+	assert.Equal("# REX request", docs[0].Comment)
+	assert.Equal(map[string]string{"direction": "request"}, docs[0].Meta)
+	assert.Equal(map[string]string{"direction": "response", "delay": "100"}, docs[1].Meta)
+	assert.Nil(docs[2].Meta)
+
+	bin, err := Marshal(docs[1].Elements)
+	assert.NoError(err)
+	assert.Equal(T8L16{2, 0, 12, 9, 0, 9, 10, 0, 2, 0, 1, 11, 0, 1, 2}, bin)
+	bin, err = Marshal(docs[2].Elements)
+	assert.NoError(err)
+	assert.Equal(T8L16{3, 0, 1, 1}, bin)
+
+	// The stringified stream decodes back to the same documents
+	str, err := StringifyAll(docs)
+	assert.NoError(err)
+	again, err := DecodeAll(strings.NewReader(str))
+	assert.NoError(err)
+	if assert.Len(again, 3) {
+		for i := range docs {
+			assert.Equal(docs[i].Comment, again[i].Comment)
+			assert.Equal(docs[i].Meta, again[i].Meta)
+			exp, _ := Marshal(docs[i].Elements)
+			bin, _ := Marshal(again[i].Elements)
+			assert.Equal(exp, bin)
+		}
+	}
+
+	// The strict mode takes the declared metadata keys only
+	typo := "direction: request\nREX(2: 1\n"
+	docs, err = DecodeAllWithOptions(strings.NewReader(typo), DecodeOptions{Strict: true, MetaKeys: []string{"direction"}})
+	assert.ErrorIs(err, ErrUnsupportedKey)
+	if assert.Len(docs, 1) {
+		assert.Equal(map[string]string{"direction": "request"}, docs[0].Meta)
+	}
+	_, err = DecodeAllWithOptions(strings.NewReader(typo), DecodeOptions{Strict: true})
+	assert.ErrorIs(err, ErrUnsupportedKey)
+	docs, err = DecodeAllWithOptions(strings.NewReader(typo), DecodeOptions{MetaKeys: []string{"direction"}})
+	assert.NoError(err)
+	if assert.Len(docs, 1) {
+		assert.Equal(map[string]string{"direction": "request"}, docs[0].Meta)
+		assert.Len(docs[0].Elements, 1)
+	}
+
+	// The errors are located in the stream
+	_, err = DecodeAll(strings.NewReader("A(1): 1\n---\nB(2): bad\n"))
+	var e *DecodeError
+	if assert.ErrorAs(err, &e) {
+		assert.Equal(3, e.Line)
+	}
+}
@@ -0,0 +1,128 @@
+package tlv
+
+// This file has annotated hex dump of TLV data.
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/cloudcopper/core/encoding/binary"
+)
+
+// The dumpWidth is the number of value octets per dump line
+var dumpWidth = 16
+
+// The dumpColumn is the column of annotation in dump line
+var dumpColumn = 56
+
+// The dumpMaxLevel caps the indent of deeply nested TLVs,
+// so the dump of hostile data does not grow quadratic with its size
+var dumpMaxLevel = 16
+
+// Dump returns hex dump of TLV data, one TLV header per line,
+// annotated by type names of dictionary dict, which may be nil.
+// The values are dumped below their headers, indented by nesting level
+// (up to 16 levels, the deeper TLVs are not indented more).
+// The data not being TLV is dumped with the annotation of the reason.
+// The dump is parsed back into data by ParseHex, so it is usable as test golden file.
+//
+//	01 00 07                                            # IRA(1)
+//	    09 00 04                                        # Sequence(9)
+//	        0b 00 01                                    # Operation(11)
+//	            07
+func Dump(data T8L16, dict Dictionary) string {
+	var b strings.Builder
+	dump(&b, data, dict, 0)
+	return b.String()
+}
+
+func dump(b *strings.Builder, data T8L16, dict Dictionary, level int) {
+	for len(data) > 0 {
+		if len(data) < 3 {
+			dumpLine(b, data, level, "not enough data")
+			return
+		}
+		t := int(data[0])
+		l := int(binary.NetworkByteOrder.Uint16(data[1:]))
+		if len(data) < 3+l {
+			dumpLine(b, data[:3], level, fmt.Sprintf("%d: length %d exceeds data", t, l))
+			dumpValue(b, data[3:], level+1)
+			return
+		}
+
+		def := dict[t]
+		name := fmt.Sprintf("%d", t)
+		if def != nil {
+			name = fmt.Sprintf("%s(%d)", def.Name, t)
+		}
+		dumpLine(b, data[:3], level, name)
+
+		// The value is nested TLVs, if dictionary says so,
+		// or if it is well formed TLVs for unknown type
+		v := data[3 : 3+l]
+		sub := false
+		if l > 0 && (def == nil || def.Sub != nil || def.VendorID != 0) {
+			_, sub = checkT8L16(v)
+		}
+		if sub {
+			var d Dictionary
+			if def != nil {
+				d, _ = def.subOf(v)
+			}
+			dump(b, v, d, level+1)
+		} else {
+			dumpValue(b, v, level+1)
+		}
+		data = data[3+l:]
+	}
+}
+
+func dumpValue(b *strings.Builder, v []byte, level int) {
+	for len(v) > 0 {
+		n := min(len(v), dumpWidth)
+		dumpLine(b, v[:n], level, "")
+		v = v[n:]
+	}
+}
+
+func dumpLine(b *strings.Builder, data []byte, level int, comment string) {
+	start := b.Len()
+	for range spaces * min(level, dumpMaxLevel) {
+		b.WriteByte(' ')
+	}
+	for i, c := range data {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(hex.EncodeToString([]byte{c}))
+	}
+	if comment != "" {
+		for range max(1, dumpColumn-(b.Len()-start)) {
+			b.WriteByte(' ')
+		}
+		b.WriteString("# " + comment)
+	}
+	b.WriteString("\n")
+}
+
+// ParseHex parses hex text into data.
+// The text is hex octets separated by white spaces,
+// the text from "#" to the end of line is a comment.
+// It parses the output of Dump.
+func ParseHex(str string) (T8L16, error) {
+	out := T8L16{}
+	s := bufio.NewScanner(strings.NewReader(str))
+	for n := 1; s.Scan(); n++ {
+		line, _, _ := strings.Cut(s.Text(), "#")
+		for _, f := range strings.Fields(line) {
+			v, err := hex.DecodeString(f)
+			if err != nil {
+				return nil, &DecodeError{Offset: -1, Line: n, Value: f, Err: ErrWrongFormat}
+			}
+			out = append(out, v...)
+		}
+	}
+	return out, s.Err()
+}
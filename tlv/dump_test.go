@@ -0,0 +1,63 @@
+package tlv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDump(t *testing.T) {
+	assert := assert.New(t)
+
+	dict := Dictionary{
+		9: {Name: "Sequence", Type: 9, Sub: Dictionary{
+			11: {Name: "Operation", Type: 11, Size: 1},
+		}},
+		5: {Name: "CoreName", Type: 5},
+	}
+	data := T8L16{9, 0, 4, 11, 0, 1, 7, 5, 0, 3, 5, 0, 0, 1, 0, 9}
+	str := Dump(data, dict)
+	assert.Equal(`09 00 04                                                # Sequence(9)
+    0b 00 01                                            # Operation(11)
+        07
+05 00 03                                                # CoreName(5)
+    05 00 00
+01 00 09                                                # 1: length 9 exceeds data
+`, str)
+
+	bin, err := ParseHex(str)
+	assert.NoError(err)
+	assert.Equal(data, bin)
+}
+
+func TestParseHex(t *testing.T) {
+	assert := assert.New(t)
+
+	bin, err := ParseHex("0100 02 # header\n\n  aabb # value\n")
+	assert.NoError(err)
+	assert.Equal(T8L16{1, 0, 2, 0xaa, 0xbb}, bin)
+
+	_, err = ParseHex("01 00 02\n0xaa\n")
+	assert.True(errors.Is(err, ErrWrongFormat))
+	assert.EqualError(err, `at line 2: wrong format "0xaa"`)
+}
+
+func TestDumpDeep(t *testing.T) {
+	assert := assert.New(t)
+
+	// The deep nesting is not indented beyond dumpMaxLevel
+	const n = 20000
+	data := T8L16{}
+	for i := 0; i < n; i++ {
+		l := 3 * (n - 1 - i)
+		data = append(data, 1, byte(l>>8), byte(l))
+	}
+	s := Dump(data, nil)
+	assert.Less(len(s), n*(spaces*dumpMaxLevel+dumpColumn))
+	assert.Contains(s, "\n"+strings.Repeat(" ", spaces*dumpMaxLevel)+"01 00 00")
+	bin, err := ParseHex(s)
+	assert.NoError(err)
+	assert.Equal(data, bin)
+}
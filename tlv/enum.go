@@ -0,0 +1,65 @@
+package tlv
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+)
+
+// Enum names the values of enumerated value.
+// The key is the value.
+type Enum map[uint64]string
+
+// EnumOf returns Enum of Go type implementing encoding/tlv.TLVEnum
+func EnumOf(e tlv.TLVEnum) Enum {
+	return Enum(e.TLVEnumValues())
+}
+
+// Format returns named value in form "Name(value)", i.e. "Principal(2)".
+// It returns false, if the value has no name.
+func (e Enum) Format(v T8L16) (string, bool) {
+	if len(v) == 0 || len(v) > 8 {
+		return "", false
+	}
+
+	var u uint64
+	for _, b := range v {
+		u = u<<8 | uint64(b)
+	}
+	name, ok := e[u]
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s(%d)", name, u), true
+}
+
+// Value returns value by name
+func (e Enum) Value(name string) (uint64, bool) {
+	for u, n := range e {
+		if n == name {
+			return u, true
+		}
+	}
+	return 0, false
+}
+
+// Values returns sorted values of all names
+func (e Enum) Values() []uint64 {
+	values := make([]uint64, 0, len(e))
+	for u := range e {
+		values = append(values, u)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values
+}
+
+// Names returns all names ordered by value
+func (e Enum) Names() []string {
+	names := make([]string, 0, len(e))
+	for _, u := range e.Values() {
+		names = append(names, e[u])
+	}
+	return names
+}
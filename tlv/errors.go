@@ -1,16 +1,89 @@
 package tlv
 
-import "errors"
+import (
+	"errors"
+	"strings"
 
-var errNoYamlDocumentNode = errors.New("no yaml document node found")
-var errUnsupportedInputType = errors.New("unsupported input type")
-var errUnsupportedKey = errors.New("unsupported key")
-var errUnsupportedValue = errors.New("unsupported value")
-var errUnsupportedValueType = errors.New("unsupported value type")
-var errTlvUnmarshalNotEnoughData = errors.New("not enough data to unmarshal TLV")
-var errCanNotParseSliceOfBytes = errors.New("can not parse slice of bytes")
-var errStringTooShort = errors.New("string too short")
-var errWrongFormat = errors.New("wrong format")
-var errUnsupportedYamlNodeKind = errors.New("unsupported yaml node kind")
-var errYamlMappingNodeWrongContentSize = errors.New("yaml node mapping has wrong content size")
-var errNotAllYamlNodesProcessed = errors.New("not all yaml nodes processed")
+	"github.com/cloudcopper/core/encoding/tlv"
+)
+
+// DecodeError is the error locating failed TLV or YAML node, and wrapping the cause.
+// The Offset is set by UnmarshalT8L16, the Line, Column and Value are set by Decode.
+// The cause is one of errors below, or the error of underlying package.
+type DecodeError = tlv.DecodeError
+
+// DecodeErrors is list of all errors found by Decode
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+	a := make([]string, len(e))
+	for i := range e {
+		a[i] = e[i].Error()
+	}
+	return strings.Join(a, "\n")
+}
+
+// Unwrap allows errors.Is and errors.As to match any of errors
+func (e DecodeErrors) Unwrap() []error {
+	a := make([]error, len(e))
+	for i := range e {
+		a[i] = e[i]
+	}
+	return a
+}
+
+var (
+	// ErrNoYamlDocumentNode is the error when YAML has no document
+	ErrNoYamlDocumentNode = errors.New("no yaml document node found")
+	// ErrUnsupportedInputType is the error when Unmarshal is given unknown input
+	ErrUnsupportedInputType = errors.New("unsupported input type")
+	// ErrUnsupportedKey is the error when YAML key is not a TLV type
+	ErrUnsupportedKey = errors.New("unsupported key")
+	// ErrUnsupportedValue is the error when YAML value can not be converted to TLV value
+	ErrUnsupportedValue = errors.New("unsupported value")
+	// ErrUnsupportedValueType is the error when element can not be stringified
+	ErrUnsupportedValueType = errors.New("unsupported value type")
+	// ErrTlvUnmarshalNotEnoughData is the error when TLV length exceeds data
+	ErrTlvUnmarshalNotEnoughData = errors.New("not enough data to unmarshal TLV")
+	// ErrCanNotParseSliceOfBytes is the error when string is not a slice of bytes
+	ErrCanNotParseSliceOfBytes = errors.New("can not parse slice of bytes")
+	// ErrStringTooShort is the error when string is too short for expected format
+	ErrStringTooShort = errors.New("string too short")
+	// ErrWrongFormat is the error when string has unexpected format
+	ErrWrongFormat = errors.New("wrong format")
+	// ErrUnsupportedYamlNodeKind is the error when YAML node can not be TLV
+	ErrUnsupportedYamlNodeKind = errors.New("unsupported yaml node kind")
+	// ErrYamlMappingNodeWrongContentSize is the error when YAML mapping has odd number of nodes
+	ErrYamlMappingNodeWrongContentSize = errors.New("yaml node mapping has wrong content size")
+	// ErrNotAllYamlNodesProcessed is the error when some of YAML nodes are left
+	ErrNotAllYamlNodesProcessed = errors.New("not all yaml nodes processed")
+	// ErrBadDefinition is the error when dictionary definition has no name or bad type
+	ErrBadDefinition = errors.New("bad definition")
+	// ErrDuplicateDefinition is the error when dictionary defines the same type twice
+	ErrDuplicateDefinition = errors.New("duplicate definition")
+	// ErrUnknownFlag is the error when flag is neither named nor a bit number
+	ErrUnknownFlag = errors.New("unknown flag")
+	// ErrValueOutOfRange is the error when value does not fit its size or range
+	ErrValueOutOfRange = errors.New("value out of range")
+	// ErrEnumMismatch is the error when "Name(N)" does not match enumerated value N
+	ErrEnumMismatch = errors.New("enumerated value name mismatch")
+	// ErrNotAllowed is the error when TLV is not defined within its parent
+	ErrNotAllowed = errors.New("not allowed within parent")
+	// ErrTooMany is the error when non repeated TLV is present more than once
+	ErrTooMany = errors.New("present more than once")
+	// ErrRequired is the error when required TLV is missing
+	ErrRequired = errors.New("required but missing")
+	// ErrBadLength is the error when TLV value length does not match definition
+	ErrBadLength = errors.New("bad value length")
+	// ErrTypeOutOfRange is the error when TLV type does not fit 8 bits in strict mode
+	ErrTypeOutOfRange = errors.New("type out of range")
+	// ErrUndefinedVariable is the error when template has parameter not given
+	ErrUndefinedVariable = errors.New("undefined variable")
+	// ErrBadRepeat is the error when repeat of template has From greater than To
+	ErrBadRepeat = errors.New("bad repeat range")
+	// ErrLimitExceeded is the error when data exceeds Limits of options
+	ErrLimitExceeded error = tlv.ErrLimitExceeded
+	// ErrValueTooLong is the error when TLV value does not fit 16 bits length
+	// (the same as of encoding/tlv, i.e. AppendT8L16)
+	ErrValueTooLong error = tlv.ErrValueTooLong
+)
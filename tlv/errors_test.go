@@ -0,0 +1,114 @@
+package tlv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeError(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `
+Sequence(9):
+    SequenceNumber(10): 5
+    Operation(11): Bad(7
+`
+	_, err := Decode(yaml)
+	assert.ErrorIs(err, ErrUnsupportedValue)
+	var e *DecodeError
+	if assert.ErrorAs(err, &e) {
+		assert.Equal(4, e.Line)
+		assert.Equal(20, e.Column)
+		assert.Equal([]int{9, 11}, e.Path)
+		assert.Equal([]string{"Sequence", "Operation"}, e.Names)
+		assert.Equal("Bad(7", e.Value)
+		assert.Equal(`Sequence(9)/Operation(11) at line 4, column 20: unsupported value "Bad(7"`, e.Error())
+	}
+
+	_, err = Decode("Sequence(9):\n    Bad(): 1\n")
+	assert.ErrorIs(err, ErrUnsupportedKey)
+	if assert.ErrorAs(err, &e) {
+		assert.Equal(2, e.Line)
+		assert.Equal([]int{9}, e.Path)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `
+IRA(1):
+    Sequence(9):
+        SequenceNumber(10): [0, 0x100]
+        CcapCoreIdentification(60):
+            Index(1): 1
+            VendorId(6): 1.2.3
+            CoreMode(7): 1
+        Operation(11): 7
+`
+	out, err := Decode(yaml)
+	if assert.IsType(DecodeErrors{}, err) {
+		errs := err.(DecodeErrors)
+		if assert.Len(errs, 2) {
+			assert.Equal([]int{1, 9, 10}, errs[0].Path)
+			assert.Equal(4, errs[0].Line)
+			assert.Equal(33, errs[0].Column)
+			assert.Equal("0x100", errs[0].Value)
+			assert.Equal("IRA(1)/Sequence(9)/CcapCoreIdentification(60)/VendorId(6)", errs[1].PathString())
+			assert.Equal(`IRA(1)/Sequence(9)/CcapCoreIdentification(60)/VendorId(6) at line 7, column 26: unsupported value "1.2.3"`, errs[1].Error())
+		}
+	}
+	// The rest of elements is decoded
+	if assert.Len(out, 1) && assert.Len(out[0].Sub, 1) {
+		seq := out[0].Sub[0].Sub
+		assert.Len(seq, 2)
+		assert.Len(seq[0].Sub, 2)
+	}
+
+	_, err = Decode("IRA(1):\n  - a\n b: 1\n")
+	var e *DecodeError
+	if assert.ErrorAs(err, &e) {
+		assert.Equal(2, e.Line)
+	}
+}
+
+func TestUnmarshalT8L16Error(t *testing.T) {
+	assert := assert.New(t)
+
+	out := Elements{}
+	err := UnmarshalT8L16(T8L16{9, 0, 1, 1, 10, 0, 2, 0}, &out)
+	assert.ErrorIs(err, ErrTlvUnmarshalNotEnoughData)
+	var e *DecodeError
+	if assert.ErrorAs(err, &e) {
+		assert.Equal(4, e.Offset)
+		assert.Equal("at offset 4: not enough data to unmarshal TLV", e.Error())
+	}
+
+	assert.ErrorIs(Unmarshal([]byte{}, &out), ErrUnsupportedInputType)
+}
+
+func TestMarshalError(t *testing.T) {
+	assert := assert.New(t)
+
+	in := Elements{{Name: "Sequence", T: 9, Sub: Elements{
+		{Name: "Data", T: 1, V: make(T8L16, 0x10000)},
+	}}}
+	_, err := Marshal(in)
+	assert.ErrorIs(err, ErrValueTooLong)
+	assert.ErrorIs(err, tlv.ErrValueTooLong)
+	var e *DecodeError
+	if assert.ErrorAs(err, &e) {
+		assert.Equal([]int{9, 1}, e.Path)
+		assert.Equal("Sequence(9)/Data(1): value too long", e.Error())
+	}
+
+	in[0].Sub[0].V = make(T8L16, 0xFFFF)
+	in[0].Sub = append(in[0].Sub, in[0].Sub[0])
+	_, err = Marshal(in)
+	if assert.True(errors.As(err, &e)) {
+		assert.Equal([]int{9}, e.Path)
+	}
+}
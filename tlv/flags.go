@@ -0,0 +1,90 @@
+package tlv
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlagSet names the bits of bitmask value.
+// The key is the bit number, where bit 0 is the least significant bit.
+type FlagSet map[uint]string
+
+// Format returns list of named flags set in v, i.e. "[FlagA, FlagC]".
+// It returns false, if v has no bits set, or any bit set has no name.
+func (f FlagSet) Format(v T8L16) (string, bool) {
+	if len(v) > 8 {
+		return "", false
+	}
+
+	var u uint64
+	for _, b := range v {
+		u = u<<8 | uint64(b)
+	}
+	if u == 0 {
+		return "", false
+	}
+
+	names := make([]string, 0, 8)
+	for bit := uint(0); bit < 64; bit++ {
+		if u&(1<<bit) == 0 {
+			continue
+		}
+		name, ok := f[bit]
+		if !ok {
+			return "", false
+		}
+		names = append(names, name)
+	}
+
+	return "[" + strings.Join(names, ", ") + "]", true
+}
+
+// Parse returns bitmask value of size octets with given flags set.
+// The flag is either a name, or a bit number.
+// If size is 0, the value is the smallest of 1, 2, 4 or 8 octets fitting all flags.
+func (f FlagSet) Parse(flags []string, size int) (T8L16, error) {
+	var u uint64
+	for _, s := range flags {
+		bit, ok := f.bit(s)
+		if !ok {
+			n, err := strconv.ParseUint(s, 0, 6)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", s, ErrUnknownFlag)
+			}
+			bit = uint(n)
+		}
+		u |= 1 << bit
+	}
+
+	return putUint(u, size)
+}
+
+func (f FlagSet) bit(name string) (uint, bool) {
+	for bit, n := range f {
+		if n == name {
+			return bit, true
+		}
+	}
+	return 0, false
+}
+
+// Bits returns sorted bit numbers of all flags
+func (f FlagSet) Bits() []uint {
+	bits := make([]uint, 0, len(f))
+	for bit := range f {
+		bits = append(bits, bit)
+	}
+	sort.Slice(bits, func(i, j int) bool { return bits[i] < bits[j] })
+	return bits
+}
+
+// Names returns names of all flags ordered by bit number
+func (f FlagSet) Names() []string {
+	names := make([]string, 0, len(f))
+	for _, bit := range f.Bits() {
+		names = append(names, f[bit])
+	}
+	return names
+}
@@ -0,0 +1,96 @@
+package tlv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// The fuzz targets check, that hostile input never panics the decoders.
+// The seeds are run as tests, "go test -fuzz=FuzzDecode" runs the fuzzing.
+
+// The fuzzLimits keeps the fuzzing of templates and aliases fast
+var fuzzLimits = Limits{MaxDepth: 32, MaxElements: 1000, MaxBytes: 1 << 16}
+
+var fuzzDict = Dictionary{
+	9: {Name: "Sequence", Type: 9, Required: true, Sub: Dictionary{
+		10: {Name: "SequenceNumber", Type: 10, Size: 2},
+		11: {Name: "Operation", Type: 11, Size: 1, Enum: Enum{1: "Read", 2: "Write"}},
+		60: {Name: "CcapCoreIdentification", Type: 60, Repeated: true, Sub: Dictionary{
+			5:  {Name: "CoreName", Type: 5, MaxLen: 16},
+			10: {Name: "CoreFunction", Type: 10, Size: 2, Flags: FlagSet{0: "Principal", 4: "Video"}},
+		}},
+	}},
+}
+
+func FuzzUnmarshalT8L16(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{9, 0, 13, 10, 0, 2, 0, 1, 60, 0, 5, 10, 0, 2, 0x10, 0x01})
+	f.Add([]byte{1, 0, 4, 2, 0, 3, 7})
+	f.Add([]byte{1, 0, 0, 2})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out := Elements{}
+		err := UnmarshalT8L16WithOptions(data, &out, UnmarshalOptions{Limits: fuzzLimits})
+		if err == nil {
+			// The generic structure keeps all of data
+			bin, err := Marshal(out)
+			if err != nil || !bytes.Equal(bin, data) {
+				t.Fatalf("marshal %v of %v: %v", bin, data, err)
+			}
+			fuzzDict.Annotate(out)
+			if _, err := Stringify(out); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The dump is parsed back into data
+		bin, err := ParseHex(Dump(data, fuzzDict))
+		if err != nil || !bytes.Equal(bin, data) {
+			t.Fatalf("dump %v of %v: %v", bin, data, err)
+		}
+
+		_ = fuzzDict.ValidateT8L16(data)
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add("Sequence(9):\n    SequenceNumber(10): uint16(1)\n    Operation(11): Write\n")
+	f.Add("- 1: &a\n    2: 0x02\n- 3:\n    <<: *a\n")
+	f.Add("repeat(ch, 1..4):\n    Sequence:\n        CoreFunction(10): [Principal, ${ch}]\n")
+	f.Add("1: [0, 0x100]\n2: 10.0.0.1\n3: time(2025-11-29T08:30:22Z)\n")
+	f.Add("direction: request\n1: null\n---\n# comment\n2: \"text\"\n")
+	f.Fuzz(func(t *testing.T, str string) {
+		for _, opts := range []DecodeOptions{
+			{Limits: fuzzLimits},
+			{Limits: fuzzLimits, Dictionary: fuzzDict, Strict: true, ResolveNames: true},
+			{Limits: fuzzLimits, Template: true, Vars: map[string]string{"ch": "1"}},
+		} {
+			out, _ := DecodeWithOptions(str, opts)
+			if _, err := Stringify(out); err != nil {
+				t.Fatal(err)
+			}
+			_, _ = Marshal(out)
+
+			docs, _ := DecodeAllWithOptions(strings.NewReader(str), opts)
+			if _, err := StringifyAll(docs); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+func FuzzParseDictionary(f *testing.F) {
+	f.Add([]byte("- name: Sequence\n  type: 9\n  sub:\n    - name: SequenceNumber\n      type: 10\n      size: 2\n"))
+	f.Add([]byte("- name: CoreMode\n  type: 7\n  enum: {1: Active}\n  flags: {0: Principal}\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = ParseDictionary(data)
+	})
+}
+
+func FuzzParseHex(f *testing.F) {
+	f.Add("09 00 04 # Sequence(9)\n    0b 00 01\n        07\n")
+	f.Add("0100 02 # header\n\n  aabb # value\n")
+	f.Fuzz(func(t *testing.T, str string) {
+		_, _ = ParseHex(str)
+	})
+}
@@ -4,15 +4,29 @@ import (
 	"os"
 
 	"github.com/cloudcopper/core/encoding/binary"
-	"github.com/pkg/errors"
 )
 
 // TODO Check bytes.Buffer - would it be nicer to use here? consider less SLOC
 var chunkSize = os.Getpagesize()
 
-// Marshal encode generic TLV structure into TLV data
+// Marshal encode generic TLV structure into TLV data.
+// The elements with type out of 0..255 are skipped.
+// The error is DecodeError with path of the element,
+// which value or sub-elements do not fit 16 bits length.
 func Marshal(in Elements) (T8L16, error) {
-	strict := false
+	return MarshalWithOptions(in, MarshalOptions{})
+}
+
+// MarshalOptions controls the encoding of TLV data.
+// The zero value is the default behaviour of Marshal.
+type MarshalOptions struct {
+	// Strict makes elements with type out of 0..255 an error,
+	// instead of skipping them.
+	Strict bool
+}
+
+// MarshalWithOptions is the Marshal controlled by opts
+func MarshalWithOptions(in Elements, opts MarshalOptions) (T8L16, error) {
 	buf := make([]byte, chunkSize)
 	pos := chunkSize
 	// The resize function increases buf to fit at least r more bytes
@@ -30,23 +44,38 @@ func Marshal(in Elements) (T8L16, error) {
 	}
 
 	size := 0
+	var path Elements // the parents of current elements
+	fail := func(el *Element, err error) error {
+		e := &DecodeError{Offset: -1, Err: err}
+		for _, p := range append(path, *el) {
+			e.Path = append(e.Path, p.T)
+			e.Names = append(e.Names, p.Name)
+		}
+		return e
+	}
 	var f func(Elements) error
 	f = func(in Elements) error {
 		for i := len(in) - 1; i >= 0; i-- {
 			el := &(in)[i]
 
-			if !strict {
-				if el.T < 0 || el.T > 255 {
-					continue
+			if el.T < 0 || el.T > 255 {
+				if opts.Strict {
+					return fail(el, ErrTypeOutOfRange)
 				}
+				continue
 			}
 
 			switch {
 			case el.Sub != nil:
 				bk := size
 				size = 0
+				path = append(path, *el)
 				if err := f(el.Sub); err != nil {
-					return errors.WithStack(err)
+					return err
+				}
+				path = path[:len(path)-1]
+				if size > 0xFFFF {
+					return fail(el, ErrValueTooLong)
 				}
 				if pos < 3 {
 					resize(3)
@@ -62,6 +91,9 @@ func Marshal(in Elements) (T8L16, error) {
 
 			case el.Sub == nil:
 				l := len(el.V)
+				if l > 0xFFFF {
+					return fail(el, ErrValueTooLong)
+				}
 				r := l + 3 /*T 1byte, L 2bytes, V xbytes*/
 				for pos <= r {
 					resize(r)
@@ -84,7 +116,7 @@ func Marshal(in Elements) (T8L16, error) {
 		return nil
 	}
 	if err := f(in); err != nil {
-		return nil, errors.WithStack(err)
+		return nil, err
 	}
 
 	return buf[pos:], nil
@@ -0,0 +1,96 @@
+package tlv
+
+// This file has typed scalar values of YAML, i.e. "uint32(5000000)".
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+)
+
+// The scalarFunc converts argument of typed scalar to value
+type scalarFunc func(arg string) (T8L16, error)
+
+// The scalarFuncs are typed scalars by name.
+// The overflow of integer is an error, as the width is given explicitly.
+var scalarFuncs = map[string]scalarFunc{
+	"uint8":  scalarUint(1),
+	"uint16": scalarUint(2),
+	"uint32": scalarUint(4),
+	"uint64": scalarUint(8),
+	"int8":   scalarInt(1),
+	"int16":  scalarInt(2),
+	"int32":  scalarInt(4),
+	"int64":  scalarInt(8),
+	"hex":    scalarHex,
+	"b64":    scalarBase64,
+	"time":   scalarTime,
+	"ipv6":   scalarIPv6,
+}
+
+var reScalar = regexp.MustCompile(`^([a-z0-9]+)\((.*)\)$`)
+
+// The decodeScalar decodes typed scalar s.
+// The false is returned if s is not a typed scalar.
+func decodeScalar(s string) (T8L16, bool, error) {
+	m := reScalar.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false, nil
+	}
+	f, ok := scalarFuncs[m[1]]
+	if !ok {
+		return nil, false, nil
+	}
+	v, err := f(m[2])
+	return v, true, err
+}
+
+func scalarUint(size int) scalarFunc {
+	return func(arg string) (T8L16, error) {
+		u, err := strconv.ParseUint(arg, 0, 8*size)
+		if err != nil {
+			return nil, err
+		}
+		return putUint(u, size)
+	}
+}
+
+func scalarInt(size int) scalarFunc {
+	return func(arg string) (T8L16, error) {
+		i, err := strconv.ParseInt(arg, 0, 8*size)
+		if err != nil {
+			return nil, err
+		}
+		return tlv.AppendUint(nil, uint64(i), size), nil
+	}
+}
+
+func scalarHex(arg string) (T8L16, error) {
+	return hex.DecodeString(arg)
+}
+
+func scalarBase64(arg string) (T8L16, error) {
+	return base64.StdEncoding.DecodeString(arg)
+}
+
+func scalarTime(arg string) (T8L16, error) {
+	t, err := time.Parse(time.RFC3339Nano, arg)
+	if err != nil {
+		return nil, err
+	}
+	return tlv.AppendTime(nil, t), nil
+}
+
+// The scalarIPv6 keeps IPv4-mapped IPv6 address in 16 octets
+func scalarIPv6(arg string) (T8L16, error) {
+	ip := net.ParseIP(arg)
+	if ip == nil {
+		return nil, ErrUnsupportedValue
+	}
+	return T8L16(ip.To16()), nil
+}
@@ -0,0 +1,58 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeScalar(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		yaml string
+		bin  T8L16
+	}{
+		{"A(1): uint8(0x12)", T8L16{1, 0, 1, 0x12}},
+		{"A(1): uint16(5)", T8L16{1, 0, 2, 0, 5}},
+		{"A(1): uint32(1000000000)", T8L16{1, 0, 4, 0x3B, 0x9A, 0xCA, 0x00}},
+		{"A(1): uint64(1)", T8L16{1, 0, 8, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{"A(1): int8(-1)", T8L16{1, 0, 1, 0xFF}},
+		{"A(1): int16(-2)", T8L16{1, 0, 2, 0xFF, 0xFE}},
+		{"A(1): int32(-2)", T8L16{1, 0, 4, 0xFF, 0xFF, 0xFF, 0xFE}},
+		{"A(1): hex(0a0B0c)", T8L16{1, 0, 3, 0x0A, 0x0B, 0x0C}},
+		{"A(1): b64(AQID)", T8L16{1, 0, 3, 1, 2, 3}},
+		{"A(1): time(2025-11-29T08:30:22.7Z)", T8L16{1, 0, 8, 0x07, 0xE9, 11, 29, 8, 30, 22, 7}},
+		{"A(1): time(2025-11-29T08:30:22-03:30)", T8L16{1, 0, 11, 0x07, 0xE9, 11, 29, 8, 30, 22, 0, '-', 3, 30}},
+		{"A(1): ::ffff:10.0.0.1", T8L16{1, 0, 4, 10, 0, 0, 1}},
+		{"A(1): ipv6(::ffff:10.0.0.1)", T8L16{1, 0, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xFF, 0xFF, 10, 0, 0, 1}},
+		{"A(1): [1, uint16(2), hex(0304), 5]", T8L16{1, 0, 6, 1, 0, 2, 3, 4, 5}},
+		// The existing syntax is not affected
+		{"A(1): 7", T8L16{1, 0, 1, 7}},
+		{"A(1): Backup(2)", T8L16{1, 0, 1, 2}},
+		{"A(1): Freq(5000000)", T8L16{1, 0, 4, 0, 0x4C, 0x4B, 0x40}},
+	}
+	for _, c := range cases {
+		out, err := Decode(c.yaml)
+		if !assert.NoError(err, c.yaml) {
+			continue
+		}
+		bin, err := Marshal(out)
+		assert.NoError(err)
+		assert.Equal(c.bin, bin, c.yaml)
+	}
+
+	for _, s := range []string{
+		"A(1): uint8(256)",
+		"A(1): uint16(70000)",
+		"A(1): int8(128)",
+		"A(1): uint32(-1)",
+		"A(1): hex(0a0)",
+		"A(1): b64(***)",
+		"A(1): time(2025-11-29)",
+		"A(1): ipv6(10.0.0)",
+	} {
+		_, err := Decode(s)
+		assert.Error(err, s)
+	}
+}
@@ -0,0 +1,140 @@
+package tlv
+
+// This file keeps YAML source of elements,
+// so the decoded YAML may be edited and stringified back
+// with its comments and hand formatted values.
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Source is the YAML source of element kept by Decode.
+// The Stringify reproduces the comments, and the original text
+// of key and value, while those still match the element.
+type Source struct {
+	Key         string // original key, i.e. "VendorId(6)"
+	Text        string // original value, i.e. "uint16(4491)", empty for sub-elements
+	V           T8L16  // value decoded out of Text, to detect edits of element
+	HeadComment string // comment lines before element
+	LineComment string // comment at the end of element line
+	FootComment string // comment lines after element
+}
+
+// The source returns Source of element el decoded out of key and value nodes.
+// The alias value keeps no text, as its anchor is not stringified.
+func (d *yamlDecoder) source(key, value *yaml.Node, alias bool, el *Element) *Source {
+	s := &Source{
+		Key:         key.Value,
+		HeadComment: key.HeadComment,
+		LineComment: key.LineComment,
+		FootComment: key.FootComment,
+	}
+	if s.LineComment == "" {
+		s.LineComment = value.LineComment
+	}
+	if s.FootComment == "" {
+		s.FootComment = value.FootComment
+	}
+	if el.Sub == nil && !alias {
+		s.Text = d.sourceText(value)
+		s.V = append(T8L16{}, el.V...)
+	}
+	return s
+}
+
+// The sourceText returns original text of scalar or flow sequence node.
+// It is the text in source line, when it is known to be exact,
+// or the text built out of node.
+func (d *yamlDecoder) sourceText(node *yaml.Node) string {
+	text := scalarText(node)
+	if node.Kind == yaml.SequenceNode {
+		items := make([]string, len(node.Content))
+		for i, n := range node.Content {
+			items[i] = scalarText(n)
+		}
+		text = "[" + strings.Join(items, ", ") + "]"
+	}
+
+	// The template values are expanded, so the source is not exact
+	if d.opts.Template || node.Line < 1 || node.Line > len(d.lines) {
+		return text
+	}
+	line := d.lines[node.Line-1]
+	if node.Column < 1 || node.Column > len(line) {
+		return text
+	}
+	src := line[node.Column-1:]
+	if node.LineComment != "" {
+		if i := strings.LastIndex(src, node.LineComment); i >= 0 {
+			src = src[:i]
+		}
+	}
+	src = strings.TrimRight(src, " \t")
+
+	// The source is exact, if it is whole value within the line
+	switch {
+	case node.Kind == yaml.SequenceNode && strings.HasPrefix(src, "[") && strings.HasSuffix(src, "]"):
+		return src
+	case node.Kind == yaml.ScalarNode && node.Style == 0 && src == node.Value:
+		return src
+	case node.Kind == yaml.ScalarNode && node.Style == yaml.DoubleQuotedStyle && strings.HasSuffix(src, `"`):
+		return src
+	case node.Kind == yaml.ScalarNode && node.Style == yaml.SingleQuotedStyle && strings.HasSuffix(src, `'`):
+		return src
+	}
+	return text
+}
+
+// The scalarText returns YAML text of scalar node in its style
+func scalarText(node *yaml.Node) string {
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		return strconv.Quote(node.Value)
+	case yaml.SingleQuotedStyle:
+		return "'" + strings.ReplaceAll(node.Value, "'", "''") + "'"
+	}
+	return node.Value
+}
+
+// The sourceKey returns original key of rec, if it still matches rec
+func sourceKey(rec Element) (string, bool) {
+	if rec.Source == nil || rec.Source.Key == "" {
+		return "", false
+	}
+	key := rec.Source.Key
+	if t, err := strconv.ParseUint(key, 0, 64); err == nil {
+		return key, rec.Name == "" && int(t) == rec.T
+	}
+	if m := reKey.FindStringSubmatch(key); m != nil {
+		t, err := strconv.ParseUint(m[2], 0, 64)
+		return key, err == nil && m[1] == rec.Name && int(t) == rec.T
+	}
+	// The bare name resolved by dictionary
+	return key, rec.Def != nil && key == rec.Def.Name && rec.Def.Type == rec.T
+}
+
+// The sourceValue returns original text of value of rec, if the value is not changed
+func sourceValue(rec Element) (string, bool) {
+	if rec.Source == nil || rec.Source.Text == "" || rec.Sub != nil {
+		return "", false
+	}
+	return rec.Source.Text, bytes.Equal(rec.Source.V, rec.V)
+}
+
+// The writeComment writes comment lines with indent
+func writeComment(buf *bytes.Buffer, comment string, indent string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if line != "" {
+			buf.WriteString(indent)
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+}
@@ -0,0 +1,92 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceEdit(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `# The core identification
+CcapCoreIdentification(60):
+    # The index of core
+    - Index(1): uint8(1)
+    - CoreIpAddress(3): 10.0.0.1 # IPv4
+    - CoreName(5): "go-ccap"
+    - VendorId(6): uint16(4491)
+    - CoreFunction(10): [0, 0x10]
+`
+	msg, err := Decode(yaml)
+	assert.NoError(err)
+
+	// Edit the vendor and rename the core type
+	msg[0].Sub[3].V = T8L16{0x12, 0x34}
+	msg[0].Name = "Core"
+
+	str, err := Stringify(msg)
+	assert.NoError(err)
+	assert.Equal(`# The core identification
+Core(60): 
+    # The index of core
+    - Index(1): uint8(1)
+    - CoreIpAddress(3): 10.0.0.1 # IPv4
+    - CoreName(5): "go-ccap"
+    - VendorId(6): [18,52]
+    - CoreFunction(10): [0, 0x10]
+`, str)
+
+	// The stringified message decodes to same binary
+	bin1, err := Marshal(msg)
+	assert.NoError(err)
+	msg2, err := Decode(str)
+	assert.NoError(err)
+	bin2, err := Marshal(msg2)
+	assert.NoError(err)
+	assert.Equal(bin1, bin2)
+}
+
+func TestSourceListItem(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `- Sequence(9):
+    # item comment
+    - 10: [0,1]
+      11: [7] # operation
+`
+	msg, err := Decode(yaml)
+	assert.NoError(err)
+
+	str, err := Stringify(msg)
+	assert.NoError(err)
+	assert.Equal(`Sequence(9): 
+    # item comment
+    - 10: [0,1]
+    - 11: [7] # operation
+`, str)
+}
+
+func TestSourceMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `- Base(1): &base
+    # the anchor comment
+    2: 0x02
+- Copy(3):
+    <<: *base
+    4: 0x04
+`
+	msg, err := Decode(yaml)
+	assert.NoError(err)
+
+	str, err := Stringify(msg)
+	assert.NoError(err)
+	assert.Equal(`- Base(1): 
+    # the anchor comment
+    2: 0x02
+- Copy(3): 
+    - 2: 0x02
+    - 4: 0x04
+`, str)
+}
@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"strconv"
-
-	"github.com/pkg/errors"
 )
 
 var spaces = 4
@@ -18,12 +16,16 @@ func Stringify(data Elements) (string, error) {
 }
 
 func stringify(data Elements, buf *bytes.Buffer, level int) error {
-	indent := fmt.Sprintf("%*s", spaces*level, "")
+	pad := fmt.Sprintf("%*s", spaces*level, "")
+	indent := pad
 	if len(data) > 1 {
 		indent += "- "
 	}
 
 	T := func(rec Element) string {
+		if key, ok := sourceKey(rec); ok {
+			return key
+		}
 		var t string
 		if rec.Name != "" {
 			t = fmt.Sprintf("%s(%d)", rec.Name, rec.T)
@@ -34,35 +36,73 @@ func stringify(data Elements, buf *bytes.Buffer, level int) error {
 	}
 
 	for _, rec := range data {
+		var lineComment, footComment string
+		if rec.Source != nil {
+			writeComment(buf, rec.Source.HeadComment, pad)
+			if rec.Source.LineComment != "" {
+				lineComment = " " + rec.Source.LineComment
+			}
+			footComment = rec.Source.FootComment
+		}
+
 		switch {
 		case rec.Sub != nil:
 			buf.WriteString(indent)
 			buf.WriteString(fmt.Sprintf("%s: ", T(rec)))
+			buf.WriteString(lineComment)
 			buf.WriteString("\n")
 			if err := stringify(rec.Sub, buf, level+1); err != nil {
-				return errors.Wrap(err, "unable to stringify child value")
+				return fmt.Errorf("unable to stringify child value: %w", err)
 			}
 
 		case len(rec.V) == 0:
 			buf.WriteString(indent)
 			buf.WriteString(fmt.Sprintf("%s: ", T(rec)))
-			buf.WriteString("null")
+			if s, ok := sourceValue(rec); ok {
+				buf.WriteString(s)
+			} else {
+				buf.WriteString("null")
+			}
+			buf.WriteString(lineComment)
 			buf.WriteString("\n")
 
 		case rec.Sub == nil:
 			buf.WriteString(indent)
 			buf.WriteString(fmt.Sprintf("%s: ", T(rec)))
-			toBuf(rec.V, buf)
+			if s, ok := sourceValue(rec); ok {
+				buf.WriteString(s)
+			} else if s, ok := formatValue(rec); ok {
+				buf.WriteString(s)
+			} else {
+				toBuf(rec.V, buf)
+			}
+			buf.WriteString(lineComment)
 			buf.WriteString("\n")
 
 		default:
-			return errors.WithStack(errUnsupportedValueType)
+			return ErrUnsupportedValueType
 		}
+		writeComment(buf, footComment, pad)
 	}
 
 	return nil
 }
 
+// The formatValue formats value according to element definition.
+// It returns false, if the value has to be given as bytes.
+func formatValue(rec Element) (string, bool) {
+	if rec.Def == nil {
+		return "", false
+	}
+	if rec.Def.Flags != nil {
+		return rec.Def.Flags.Format(rec.V)
+	}
+	if rec.Def.Enum != nil {
+		return rec.Def.Enum.Format(rec.V)
+	}
+	return "", false
+}
+
 func toBuf(t T8L16, buf *bytes.Buffer) {
 	buf.WriteString("[")
 	for i, b := range t {
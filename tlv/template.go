@@ -0,0 +1,115 @@
+package tlv
+
+// This file has templating of YAML - parameters and repeat construct.
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DecodeTemplate decodes YAML template with parameters vars into generic TLV structure.
+// The "${name}" in keys and values is replaced by parameter name.
+// The key "repeat(name, N)" or "repeat(name, From..To)" generates
+// its TLVs N times (or From to To inclusive) in place of itself,
+// with parameter name set to index.
+//
+//	Sequence(9):
+//	    repeat(ch, 1..4):
+//	        RfChannel(16):
+//	            ChannelIndex(2): ${ch}
+func DecodeTemplate(str string, vars map[string]string) (Elements, error) {
+	return DecodeWithOptions(str, DecodeOptions{Template: true, Vars: vars})
+}
+
+var reVar = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+var reRepeat = regexp.MustCompile(`^repeat\(\s*([A-Za-z_][A-Za-z0-9_]*)\s*,\s*([0-9]+)(?:\s*\.\.\s*([0-9]+))?\s*\)$`)
+
+// The expandString replaces parameters of vars in s.
+// The unknown parameters are left as is.
+func expandString(s string, vars map[string]string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return reVar.ReplaceAllStringFunc(s, func(v string) string {
+		if value, ok := vars[v[2:len(v)-1]]; ok {
+			return value
+		}
+		return v
+	})
+}
+
+// The expandNode returns deep copy of node with parameters of vars replaced.
+// The aliases are copied too, so the anchors may use parameters.
+func expandNode(node *yaml.Node, vars map[string]string) *yaml.Node {
+	return expandNodeOnce(node, vars, map[*yaml.Node]*yaml.Node{})
+}
+
+// The expandNodeOnce copies each node once, as noted in copies,
+// so the aliases of the same anchor share its copy.
+// Otherwise the nested aliases would make exponential number of copies.
+func expandNodeOnce(node *yaml.Node, vars map[string]string, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if n, ok := copies[node]; ok {
+		return n
+	}
+	n := *node
+	copies[node] = &n
+	n.Value = expandString(n.Value, vars)
+	n.Alias = expandNodeOnce(n.Alias, vars, copies)
+	if node.Content != nil {
+		n.Content = make([]*yaml.Node, len(node.Content))
+		for i, c := range node.Content {
+			n.Content[i] = expandNodeOnce(c, vars, copies)
+		}
+	}
+	return &n
+}
+
+// The checkVars fails on parameters left in scalar node.
+func checkVars(node *yaml.Node) error {
+	if strings.Contains(node.Value, "${") && reVar.MatchString(node.Value) {
+		return ErrUndefinedVariable
+	}
+	return nil
+}
+
+// The decodeRepeat decodes the body of "repeat(name, N)" key.
+// It returns false if key is not a repeat.
+func (d *yamlDecoder) decodeRepeat(key, body *yaml.Node, dict Dictionary, out *Elements) bool {
+	m := reRepeat.FindStringSubmatch(key.Value)
+	if m == nil {
+		return false
+	}
+
+	// The "repeat(name, N)" is "repeat(name, 0..N-1)"
+	n, err := strconv.Atoi(m[2])
+	from, to := 0, n-1
+	if err == nil && m[3] != "" {
+		from = n
+		to, err = strconv.Atoi(m[3])
+	}
+	if err == nil && from > to && m[3] != "" {
+		err = ErrBadRepeat
+	}
+	if err != nil {
+		d.fail(key, nil, err)
+		return true
+	}
+	// The iterations are counted as elements before expansion,
+	// so the huge count (or nested repeats) does not loop
+	if d.limit(key, d.count(to-from+1)) {
+		return true
+	}
+
+	vars := map[string]string{}
+	for i := from; i <= to && !d.stop; i++ {
+		vars[m[1]] = strconv.Itoa(i)
+		d.decodeContent([]*yaml.Node{expandNode(body, vars)}, dict, out)
+	}
+	return true
+}
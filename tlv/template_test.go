@@ -0,0 +1,128 @@
+package tlv
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `
+Sequence(9):
+    SequenceNumber(10): uint16(${seq})
+    CcapCoreIdentification(60):
+        CoreIpAddress(3): ${coreIp}
+    repeat(ch, 1..3):
+        RfChannel(16):
+            ChannelIndex(2): ${ch}
+            repeat(i, 2):
+                Item(${i}): ['${ch}', '${i}']
+`
+	out, err := DecodeTemplate(yaml, map[string]string{"seq": "5", "coreIp": "10.0.0.1"})
+	assert.NoError(err)
+	bin, err := Marshal(out)
+	assert.NoError(err)
+
+	exp := T8L16{9, 0, 66,
+		10, 0, 2, 0, 5,
+		60, 0, 7, 3, 0, 4, 10, 0, 0, 1,
+	}
+	for ch := byte(1); ch <= 3; ch++ {
+		exp = append(exp, 16, 0, 14, 2, 0, 1, ch, 0, 0, 2, ch, 0, 1, 0, 2, ch, 1)
+	}
+	assert.Equal(exp, bin)
+
+	_, err = DecodeTemplate(yaml, map[string]string{"seq": "5"})
+	assert.ErrorIs(err, ErrUndefinedVariable)
+	var e *DecodeError
+	if assert.ErrorAs(err, &e) {
+		assert.Equal([]int{9, 60, 3}, e.Path)
+		assert.Equal("${coreIp}", e.Value)
+	}
+
+	// The repeat range is checked before expansion
+	_, err = DecodeTemplate("repeat(i, 5..1):\n    A(1): ${i}\n", nil)
+	assert.ErrorIs(err, ErrBadRepeat)
+	_, err = DecodeTemplate("repeat(i, 100000000):\n    A(1): ${i}\n", nil)
+	assert.ErrorIs(err, ErrLimitExceeded)
+	_, err = DecodeWithOptions("repeat(i, 1..11):\n    A(1): ${i}\n", DecodeOptions{Template: true, Limits: Limits{MaxElements: 10}})
+	var le *LimitError
+	if assert.ErrorAs(err, &le) {
+		assert.Equal(10, le.Max)
+	}
+
+	// The templating is off by default
+	_, err = Decode(yaml)
+	assert.ErrorIs(err, ErrUnsupportedValue)
+}
+
+func TestDecodeAnchors(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `
+Base(1): &base
+    A(1): 1
+    B(2): 2
+Copy(2): *base
+Merged(3):
+    <<: *base
+    C(3): 3
+List(4):
+    - *base
+    - D(4): 4
+`
+	out, err := Decode(yaml)
+	assert.NoError(err)
+	bin, err := Marshal(out)
+	assert.NoError(err)
+	assert.Equal(T8L16{
+		1, 0, 8, 1, 0, 1, 1, 2, 0, 1, 2,
+		2, 0, 8, 1, 0, 1, 1, 2, 0, 1, 2,
+		3, 0, 12, 1, 0, 1, 1, 2, 0, 1, 2, 3, 0, 1, 3,
+		4, 0, 12, 1, 0, 1, 1, 2, 0, 1, 2, 4, 0, 1, 4,
+	}, bin)
+}
+
+func TestDecodeAliasBomb(t *testing.T) {
+	assert := assert.New(t)
+
+	// Each level doubles the elements of previous one
+	var b strings.Builder
+	b.WriteString("L0(1): &a0\n    X(1): 1\n    Y(2): 2\n")
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&b, "L%d(%d): &a%d\n    X(1): *a%d\n    Y(2): *a%d\n", i, i+1, i, i-1, i-1)
+	}
+	bomb := b.String()
+
+	// The anchors are decoded once, and the reused elements are counted by default limit
+	done := make(chan error, 1)
+	go func() {
+		_, err := Decode(bomb)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.ErrorIs(err, ErrLimitExceeded)
+	case <-time.After(10 * time.Second):
+		t.Fatal("alias bomb is not bounded")
+	}
+
+	// The few levels are decoded as usual
+	out, err := Decode(strings.Join(strings.SplitAfter(bomb, "\n")[:9], ""))
+	assert.NoError(err)
+	bin, err := Marshal(out)
+	assert.NoError(err)
+	l0 := T8L16{1, 0, 8, 1, 0, 1, 1, 2, 0, 1, 2}
+	l1 := append(T8L16{2, 0, 22, 1, 0, 8}, append(l0[3:], append(T8L16{2, 0, 8}, l0[3:]...)...)...)
+	assert.Equal(l1, bin[len(l0):len(l0)+len(l1)])
+	assert.Len(bin, 11+25+53)
+
+	// The nested repeats are counted by the same limit
+	_, err = DecodeTemplate("repeat(i, 60000):\n    repeat(j, 60000):\n        A(1): 1\n", nil)
+	assert.ErrorIs(err, ErrLimitExceeded)
+}
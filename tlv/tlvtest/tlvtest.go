@@ -0,0 +1,217 @@
+// Package tlvtest implements golden file tests of TLV codecs.
+//
+// The test directory has YAML messages "name.yaml" and their golden files:
+//   - "name.hex" or "name.bin" with the binary TLV data of message,
+//     the hex is the annotated dump of tlv.Dump, the bin is raw data;
+//   - "name.txt" with Stringify output of the binary data.
+//
+// Each message is checked by Decode → Marshal against the binary golden,
+// by Unmarshal → Marshal to give the same binary,
+// by Unmarshal → Stringify against the text golden,
+// and by Stringify → Decode → Marshal to give the same binary again.
+// The binary mismatch is reported as diff of annotated dumps.
+//
+// The golden files are (re)generated with environment variable TLVTEST_UPDATE=1,
+// or by "go test -update", if the test package defines own flag "update",
+// as this package does not define flags of test binary:
+//
+//	// The flag is needed by "go test -update", but not by TLVTEST_UPDATE=1 go test
+//	var _ = flag.Bool("update", false, "update golden files")
+//
+// The new binary golden is hex, unless the bin file exists.
+package tlvtest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cloudcopper/core/tlv"
+)
+
+// The update checks if golden files are to be updated,
+// either by environment variable or by flag "update" of test package
+func update() bool {
+	if ok, err := strconv.ParseBool(os.Getenv("TLVTEST_UPDATE")); err == nil {
+		return ok
+	}
+	if f := flag.Lookup("update"); f != nil {
+		ok, _ := strconv.ParseBool(f.Value.String())
+		return ok
+	}
+	return false
+}
+
+// Run runs golden file test of each YAML message in directory dir,
+// as subtest named by the file.
+// The messages are decoded with opts, and the binary data is annotated by opts.Dictionary.
+func Run(t *testing.T, dir string, opts tlv.DecodeOptions) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no yaml files in %s", dir)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".yaml")
+		t.Run(name, func(t *testing.T) {
+			runFile(t, strings.TrimSuffix(file, ".yaml"), opts)
+		})
+	}
+}
+
+// The runFile checks single message of path without extension
+func runFile(t *testing.T, path string, opts tlv.DecodeOptions) {
+	dict := opts.Dictionary
+
+	src, err := os.ReadFile(path + ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := tlv.DecodeWithOptions(string(src), opts)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	bin, err := tlv.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	// Decode → Marshal
+	if update() {
+		writeBinary(t, path, bin, dict)
+	}
+	golden := readBinary(t, path)
+	equal(t, tlv.Dump(golden, dict), tlv.Dump(bin, dict), "binary of "+path+".yaml")
+
+	// Unmarshal → Marshal
+	out := tlv.Elements{}
+	if err := tlv.UnmarshalT8L16(bin, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	dict.Annotate(out)
+	bin2, err := tlv.Marshal(out)
+	if err != nil {
+		t.Fatalf("marshal of unmarshaled: %v", err)
+	}
+	equal(t, tlv.Dump(bin, dict), tlv.Dump(bin2, dict), "binary of unmarshaled "+path+".yaml")
+
+	// Unmarshal → Stringify
+	str, err := tlv.Stringify(out)
+	if err != nil {
+		t.Fatalf("stringify: %v", err)
+	}
+	if update() {
+		write(t, path+".txt", []byte(str))
+	}
+	text, err := os.ReadFile(path + ".txt")
+	if err != nil {
+		t.Fatalf("%v (run go test -update)", err)
+	}
+	equal(t, string(text), str, "stringify of "+path+".yaml")
+
+	// Stringify → Decode → Marshal
+	msg2, err := tlv.DecodeWithOptions(str, tlv.DecodeOptions{Dictionary: dict})
+	if err != nil {
+		t.Fatalf("decode of stringified: %v", err)
+	}
+	bin3, err := tlv.Marshal(msg2)
+	if err != nil {
+		t.Fatalf("marshal of stringified: %v", err)
+	}
+	equal(t, tlv.Dump(bin, dict), tlv.Dump(bin3, dict), "binary of stringified "+path+".yaml")
+}
+
+// The equal reports the mismatch of text got with the expected one as diff of lines
+func equal(t *testing.T, want, got, what string) {
+	t.Helper()
+	if want != got {
+		t.Errorf("%s mismatch (-want +got):\n%s", what, diff(want, got))
+	}
+}
+
+// The diff returns lines of a and b, which are not common to both,
+// prefixed by "-" and "+", and common lines prefixed by space.
+func diff(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// The lcs[i][j] is length of longest common lines of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var buf strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			buf.WriteString("  " + x[i] + "\n")
+			i, j = i+1, j+1
+		case j == len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1]:
+			buf.WriteString("- " + x[i] + "\n")
+			i++
+		default:
+			buf.WriteString("+ " + y[j] + "\n")
+			j++
+		}
+	}
+	return buf.String()
+}
+
+// The readBinary reads binary golden, either bin or hex
+func readBinary(t *testing.T, path string) tlv.T8L16 {
+	t.Helper()
+	if b, err := os.ReadFile(path + ".bin"); err == nil {
+		return b
+	} else if !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path + ".hex")
+	if err != nil {
+		t.Fatalf("%v (run go test -update)", err)
+	}
+	data, err := tlv.ParseHex(string(b))
+	if err != nil {
+		t.Fatalf("%s.hex: %v", path, err)
+	}
+	return data
+}
+
+// The writeBinary writes binary golden, hex unless bin exists
+func writeBinary(t *testing.T, path string, data tlv.T8L16, dict tlv.Dictionary) {
+	t.Helper()
+	if _, err := os.Stat(path + ".bin"); err == nil {
+		write(t, path+".bin", data)
+		return
+	}
+	write(t, path+".hex", []byte(tlv.Dump(data, dict)))
+}
+
+// The write writes golden file, if its content is changed
+func write(t *testing.T, file string, data []byte) {
+	t.Helper()
+	if old, err := os.ReadFile(file); err == nil && bytes.Equal(old, data) {
+		return
+	}
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("updated %s", file)
+}
@@ -0,0 +1,36 @@
+package tlvtest
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/cloudcopper/core/tlv"
+)
+
+// The flag is defined by test package, as tlvtest does not define flags
+var _ = flag.Bool("update", false, "update golden files")
+
+func TestRun(t *testing.T) {
+	Run(t, "testdata/generic", tlv.DecodeOptions{})
+}
+
+func TestRunDictionary(t *testing.T) {
+	b, err := os.ReadFile("testdata/dictionary.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict, err := tlv.ParseDictionary(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Run(t, "testdata/dict", tlv.DecodeOptions{Dictionary: dict, ResolveNames: true})
+}
+
+func TestDiff(t *testing.T) {
+	got := diff("a\nb\nc", "a\nx\nc\nd")
+	want := "  a\n- b\n+ x\n  c\n+ d\n"
+	if got != want {
+		t.Errorf("diff is %q, want %q", got, want)
+	}
+}
@@ -19,6 +19,9 @@ type Element struct {
 	// we have to use either two field or interface{}
 	V   T8L16
 	Sub Elements
+
+	Def    *Definition // optional definition from Dictionary
+	Source *Source     // optional YAML source, kept by Decode
 }
 
 func (e Element) Get(key string) (T8L16, bool) {
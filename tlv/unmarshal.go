@@ -2,52 +2,128 @@ package tlv
 
 import (
 	"github.com/cloudcopper/core/encoding/binary"
-	"github.com/pkg/errors"
+	"github.com/cloudcopper/core/encoding/tlv"
 )
 
+// Limits bounds the decoding of untrusted data (see encoding/tlv.Limits).
+type Limits = tlv.Limits
+
+// LimitError is the error of exceeded limit, it is ErrLimitExceeded.
+type LimitError = tlv.LimitError
+
+// Budget tracks the use of Limits by single decoding (see encoding/tlv.Budget).
+type Budget = tlv.Budget
+
 // Unmarshal decode TLV data into generic TLV structure
 func Unmarshal(data interface{}, out *Elements) error {
 	switch in := data.(type) {
 	case T8L16:
 		return UnmarshalT8L16(in, out)
 	}
-	return errors.WithStack(errUnsupportedInputType)
+	return ErrUnsupportedInputType
 }
 
-// UnmarshalT8L16 decode TLV data into generic TLV structure
+// UnmarshalOptions controls the decoding of TLV data into generic TLV structure.
+// The zero value is the default behaviour of UnmarshalT8L16.
+type UnmarshalOptions struct {
+	// Limits bounds the decoding of untrusted data.
+	// The MaxDepth, MaxElements and MaxChildren count the nested TLVs too,
+	// and MaxValues counts the elements.
+	Limits Limits
+}
+
+// UnmarshalT8L16 decode TLV data into generic TLV structure.
+// The value is decoded as sub-elements, when it is well formed TLVs.
+// The error is DecodeError with offset of failed TLV.
 func UnmarshalT8L16(data T8L16, out *Elements) error {
-	for len(data) > 0 {
-		if len(data) < 3 {
-			return errors.WithStack(errTlvUnmarshalNotEnoughData)
+	return UnmarshalT8L16WithOptions(data, out, UnmarshalOptions{})
+}
+
+// UnmarshalT8L16WithOptions is the UnmarshalT8L16 controlled by opts.
+//
+// The decoding takes time linear to size of data.
+// Each value is checked to be well formed TLVs by its headers only,
+// before it is decoded as sub-elements, so no TLV is decoded twice.
+func UnmarshalT8L16WithOptions(data T8L16, out *Elements, opts UnmarshalOptions) error {
+	b := tlv.NewBudget(opts.Limits)
+	if err := b.Bytes(len(data)); err != nil {
+		return &DecodeError{Offset: 0, Err: err}
+	}
+	if offset, ok := checkT8L16(data); !ok {
+		return &DecodeError{Offset: offset, Err: ErrTlvUnmarshalNotEnoughData}
+	}
+	u := &unmarshaler{budget: b}
+	if err := u.unmarshal(data, out); err != nil {
+		return err
+	}
+	return nil
+}
+
+// The checkT8L16 checks the headers of data to be well formed TLVs.
+// It returns the offset of malformed TLV and false otherwise.
+// It reads single nesting level, so it takes one step per TLV.
+func checkT8L16(data T8L16) (int, bool) {
+	offset := 0
+	for offset < len(data) {
+		if len(data)-offset < 3 {
+			return offset, false
+		}
+		l := int(binary.NetworkByteOrder.Uint16(data[offset+1:]))
+		if len(data)-offset-3 < l {
+			return offset, false
 		}
+		offset += 3 + l
+	}
+	return offset, true
+}
+
+// The unmarshaler keeps state of single UnmarshalT8L16WithOptions call.
+type unmarshaler struct {
+	budget *Budget
+	path   []int // TLV types of parents, to locate error
+	base   int   // offset of data being decoded
+}
 
-		t := int(data[0])
-		l := int(binary.NetworkByteOrder.Uint16(data[1:]))
-		var v T8L16
-		var sub Elements
+// The unmarshal decodes data, which is well formed TLVs already
+func (u *unmarshaler) unmarshal(data T8L16, out *Elements) error {
+	offset := 0
+	for offset < len(data) {
+		t := int(data[offset])
+		l := int(binary.NetworkByteOrder.Uint16(data[offset+1:]))
+		v := data[offset+3 : offset+3+l : offset+3+l]
 
-		if len(data) < 3+l {
-			return errors.WithStack(errTlvUnmarshalNotEnoughData)
+		if err := u.budget.Enter(); err != nil {
+			return u.fail(offset, t, err)
+		}
+		if err := u.budget.Value(); err != nil {
+			return u.fail(offset, t, err)
 		}
 
+		el := Element{T: t, V: v}
 		if l == 0 {
-			v = nil
-		} else {
-			v = T8L16(data[3 : 3+l])
-			sub = Elements{}
-			if err := UnmarshalT8L16(v, &sub); err != nil {
-				sub = nil
-			} else {
-				v = nil
+			el.V = nil
+		} else if _, ok := checkT8L16(v); ok {
+			el.V = nil
+			el.Sub = Elements{}
+			u.path = append(u.path, t)
+			u.base += offset + 3
+			err := u.unmarshal(v, &el.Sub)
+			u.base -= offset + 3
+			u.path = u.path[:len(u.path)-1]
+			if err != nil {
+				return err
 			}
 		}
+		*out = append(*out, el)
+		u.budget.Leave()
 
-		name := ""
-		*out = append(*out, Element{name, t, v, sub})
-
-		// Shift ...
-		data = data[3+l:]
+		offset += 3 + l
 	}
-
 	return nil
 }
+
+// The fail returns err as DecodeError of TLV t at offset
+func (u *unmarshaler) fail(offset int, t int, err error) error {
+	path := append(append([]int{}, u.path...), t)
+	return &DecodeError{Offset: u.base + offset, Path: path, Err: err}
+}
@@ -0,0 +1,127 @@
+package tlv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+	"github.com/stretchr/testify/assert"
+)
+
+// The nest returns data of n nested TLVs of type 1 with value v inside
+func nest(n int, v T8L16) T8L16 {
+	data := v
+	for i := 0; i < n; i++ {
+		data, _ = tlv.AppendT8L16(nil, 1, data)
+	}
+	return data
+}
+
+func TestUnmarshalT8L16Deep(t *testing.T) {
+	assert := assert.New(t)
+
+	// The value of each level is nested TLV, but the innermost is not,
+	// as its length exceeds data.
+	// Each level is checked to be TLVs by headers, and decoded once.
+	data := nest(10000, T8L16{2, 0, 3, 7})
+	out := Elements{}
+	assert.NoError(UnmarshalT8L16(data, &out))
+	depth := 0
+	for el := out; len(el) == 1 && el[0].Sub != nil; el = el[0].Sub {
+		depth++
+	}
+	assert.Equal(9999, depth)
+
+	bin, err := Marshal(out)
+	assert.NoError(err)
+	assert.Equal(data, bin)
+}
+
+func TestUnmarshalT8L16Limits(t *testing.T) {
+	assert := assert.New(t)
+
+	data := nest(10, T8L16{2, 0, 1, 7, 3, 0, 0})
+	out := Elements{}
+	assert.NoError(UnmarshalT8L16WithOptions(data, &out, UnmarshalOptions{Limits: Limits{MaxDepth: 11, MaxElements: 12, MaxBytes: len(data)}}))
+
+	out = Elements{}
+	err := UnmarshalT8L16WithOptions(data, &out, UnmarshalOptions{Limits: Limits{MaxDepth: 3}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+	assert.EqualError(err, "1/1/1/1 at offset 9: limit exceeded: max depth 3")
+
+	err = UnmarshalT8L16WithOptions(data, &out, UnmarshalOptions{Limits: Limits{MaxElements: 11}})
+	assert.EqualError(err, "1/1/1/1/1/1/1/1/1/1/3 at offset 34: limit exceeded: max elements 11")
+
+	err = UnmarshalT8L16WithOptions(data, &out, UnmarshalOptions{Limits: Limits{MaxBytes: 10}})
+	assert.EqualError(err, "at offset 0: limit exceeded: max bytes 10")
+}
+
+func TestDecodeLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	// The aliases of aliases make exponential number of elements
+	yaml := `- 1: &a
+    - 2: 0x02
+    - 2: 0x02
+- 3: &b
+    - <<: *a
+    - <<: *a
+- 4: &c
+    - <<: *b
+    - <<: *b
+- 5:
+    - <<: *c
+    - <<: *c
+`
+	out, err := DecodeWithOptions(yaml, DecodeOptions{Limits: Limits{MaxElements: 10}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+	assert.Len(err.(DecodeErrors), 1)
+	assert.Len(out, 2) // the aliases count too
+
+	_, err = DecodeWithOptions(yaml, DecodeOptions{Limits: Limits{MaxDepth: 2}})
+	assert.NoError(err)
+	_, err = DecodeWithOptions("1:\n    2:\n        3: 0x03\n", DecodeOptions{Limits: Limits{MaxDepth: 2}})
+	assert.EqualError(err, `1/2 at line 3, column 9: limit exceeded: max depth 2 "3"`)
+
+	_, err = DecodeWithOptions(yaml, DecodeOptions{Limits: Limits{MaxBytes: 10}})
+	assert.EqualError(err, "limit exceeded: max bytes 10")
+
+	// The repeat stops at the limit
+	_, err = DecodeWithOptions("repeat(i, 1000000000):\n    1: ${i}\n", DecodeOptions{Template: true, Limits: Limits{MaxElements: 100}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+
+	_, err = DecodeAllWithOptions(strings.NewReader(yaml), DecodeOptions{Limits: Limits{MaxBytes: 10}})
+	assert.ErrorIs(err, ErrLimitExceeded)
+}
+
+func TestUnmarshalT8L16Children(t *testing.T) {
+	assert := assert.New(t)
+
+	// The thousands of empty TLVs within single parent
+	v := T8L16{}
+	for i := 0; i < 1000; i++ {
+		v = append(v, 2, 0, 0)
+	}
+	data, _ := tlv.AppendT8L16(nil, 1, v)
+
+	out := Elements{}
+	err := UnmarshalT8L16WithOptions(data, &out, UnmarshalOptions{Limits: Limits{MaxChildren: 100}})
+	assert.EqualError(err, "1/2 at offset 303: limit exceeded: max children 100")
+	err = UnmarshalT8L16WithOptions(data, &out, UnmarshalOptions{Limits: Limits{MaxValues: 100}})
+	assert.EqualError(err, "1/2 at offset 300: limit exceeded: max values 100")
+}
+
+func TestDecodeValueLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	yaml := `1: "go-ccap"
+2: [0, 1, 2, 3]
+`
+	_, err := DecodeWithOptions(yaml, DecodeOptions{Limits: Limits{MaxStringLen: 7, MaxSliceLen: 4}})
+	assert.NoError(err)
+
+	_, err = DecodeWithOptions(yaml, DecodeOptions{Limits: Limits{MaxStringLen: 6}})
+	assert.EqualError(err, `1 at line 1, column 4: limit exceeded: max string length 6 "go-ccap"`)
+	_, err = DecodeWithOptions(yaml, DecodeOptions{Limits: Limits{MaxSliceLen: 3}})
+	assert.EqualError(err, "2 at line 2, column 4: limit exceeded: max slice length 3")
+}
@@ -0,0 +1,198 @@
+package tlv
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudcopper/core/encoding/binary"
+)
+
+// Violation is single violation of Dictionary found by validation
+type Violation struct {
+	Path   []int    // TLV types from the root
+	Names  []string // names of TLV types from the root, empty if unknown
+	Offset int      // offset of TLV in validated data
+	Err    error
+}
+
+// PathString returns path in form "IRA(1)/Sequence(9)/SequenceNumber(10)"
+func (v Violation) PathString() string {
+	a := make([]string, len(v.Path))
+	for i, t := range v.Path {
+		if v.Names[i] != "" {
+			a[i] = fmt.Sprintf("%s(%d)", v.Names[i], t)
+		} else {
+			a[i] = fmt.Sprintf("%d", t)
+		}
+	}
+	return strings.Join(a, "/")
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s at offset %d: %v", v.PathString(), v.Offset, v.Err)
+}
+
+func (v Violation) Unwrap() error { return v.Err }
+
+// Violations is list of all violations found by validation
+type Violations []Violation
+
+func (v Violations) Error() string {
+	a := make([]string, len(v))
+	for i := range v {
+		a[i] = v[i].Error()
+	}
+	return strings.Join(a, "\n")
+}
+
+// Unwrap allows errors.Is and errors.As to match any of violations
+func (v Violations) Unwrap() []error {
+	a := make([]error, len(v))
+	for i := range v {
+		a[i] = v[i]
+	}
+	return a
+}
+
+// Validate validates generic TLV structure against dictionary.
+// The data is validated as marshaled, so offsets are in the result of Marshal.
+// It returns Violations or nil.
+func (d Dictionary) Validate(data Elements) error {
+	bin, err := Marshal(data)
+	if err != nil {
+		return err
+	}
+	return d.ValidateT8L16(bin)
+}
+
+// ValidateT8L16 validates TLV data against dictionary.
+// The TLV with sub-elements in dictionary is a container,
+// and any other TLV is a value.
+// It checks:
+//   - the TLV is defined within its parent;
+//   - the required TLV is present;
+//   - the TLV not marked as repeated is present at most once;
+//   - the value has defined size or is within the length range;
+//   - the value (up to 8 octets) is within the numeric range.
+//
+// It returns Violations or nil.
+func (d Dictionary) ValidateT8L16(data T8L16) error {
+	v := validator{}
+	v.walk(data, 0, d)
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return v.violations
+}
+
+type validator struct {
+	path       []int
+	names      []string
+	violations Violations
+}
+
+func (v *validator) add(offset int, err error) {
+	v.violations = append(v.violations, Violation{
+		Path:   append([]int(nil), v.path...),
+		Names:  append([]string(nil), v.names...),
+		Offset: offset,
+		Err:    err,
+	})
+}
+
+func (v *validator) push(t int, name string) {
+	v.path = append(v.path, t)
+	v.names = append(v.names, name)
+}
+
+func (v *validator) pop() {
+	v.path = v.path[:len(v.path)-1]
+	v.names = v.names[:len(v.names)-1]
+}
+
+// The walk validates data at offset base with dict
+func (v *validator) walk(data T8L16, base int, dict Dictionary) {
+	count := map[int]int{}
+	pos := 0
+	for pos < len(data) {
+		offset := base + pos
+		if len(data)-pos < 3 {
+			v.add(offset, ErrTlvUnmarshalNotEnoughData)
+			break
+		}
+		t := int(data[pos])
+		l := int(binary.NetworkByteOrder.Uint16(data[pos+1:]))
+		if len(data)-pos-3 < l {
+			v.add(offset, ErrTlvUnmarshalNotEnoughData)
+			break
+		}
+		value := data[pos+3 : pos+3+l]
+		pos += 3 + l
+
+		def := dict[t]
+		name := ""
+		if def != nil {
+			name = def.Name
+		}
+		v.push(t, name)
+		count[t]++
+
+		switch {
+		case def == nil:
+			v.add(offset, ErrNotAllowed)
+		case count[t] > 1 && !def.Repeated:
+			v.add(offset, ErrTooMany)
+			fallthrough
+		default:
+			v.value(value, offset, def)
+		}
+
+		v.pop()
+	}
+
+	// Check required TLVs
+	for _, t := range dict.types() {
+		def := dict[t]
+		if !def.Required || count[t] != 0 {
+			continue
+		}
+		v.push(t, def.Name)
+		v.add(base, ErrRequired)
+		v.pop()
+	}
+}
+
+// The value validates value of TLV at offset with def
+func (v *validator) value(value T8L16, offset int, def *Definition) {
+	if def.Sub != nil || def.VendorID != 0 {
+		sub, known := def.subOf(value)
+		if !known && len(value) >= 3 && int(value[0]) == def.VendorID {
+			// The content of unknown vendor is not validated, but its vendor ID
+			l := int(binary.NetworkByteOrder.Uint16(value[1:]))
+			value = value[:min(len(value), 3+l)]
+		}
+		v.walk(value, offset+3, sub)
+		return
+	}
+
+	l := len(value)
+	if (def.Size != 0 && l != def.Size) || l < def.MinLen || (def.MaxLen != 0 && l > def.MaxLen) {
+		v.add(offset, ErrBadLength)
+		return
+	}
+
+	if def.Min == nil && def.Max == nil {
+		return
+	}
+	if l > 8 {
+		v.add(offset, ErrBadLength)
+		return
+	}
+	var u uint64
+	for _, b := range value {
+		u = u<<8 | uint64(b)
+	}
+	if (def.Min != nil && u < *def.Min) || (def.Max != nil && u > *def.Max) {
+		v.add(offset, ErrValueOutOfRange)
+	}
+}
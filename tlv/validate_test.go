@@ -0,0 +1,82 @@
+package tlv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testValidateDictionary = `
+- name: Sequence
+  type: 9
+  repeated: true
+  sub:
+    - name: SequenceNumber
+      type: 10
+      size: 2
+      required: true
+    - name: Operation
+      type: 11
+      size: 1
+      min: 1
+      max: 8
+    - name: CoreName
+      type: 5
+      minLen: 1
+      maxLen: 4
+    - name: CoreInfo
+      type: 60
+      sub:
+        - name: CoreId
+          type: 2
+          size: 6
+`
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	dict, err := ParseDictionary([]byte(testValidateDictionary))
+	assert.NoError(err)
+
+	data := T8L16{
+		9, 0, 12, 10, 0, 2, 0, 1, 11, 0, 1, 7, 5, 0, 0,
+		9, 0, 21, 11, 0, 1, 9, 10, 0, 1, 1, 10, 0, 2, 0, 2, 60, 0, 5, 2, 0, 2, 1, 2,
+		8, 0, 0,
+		9, 0, 1, 1,
+	}
+	err = dict.ValidateT8L16(data)
+	if assert.IsType(Violations{}, err) {
+		v := err.(Violations)
+		if assert.Len(v, 8) {
+			assert.Equal(Violation{[]int{9, 5}, []string{"Sequence", "CoreName"}, 12, ErrBadLength}, v[0])
+			assert.Equal(Violation{[]int{9, 11}, []string{"Sequence", "Operation"}, 18, ErrValueOutOfRange}, v[1])
+			assert.Equal(Violation{[]int{9, 10}, []string{"Sequence", "SequenceNumber"}, 22, ErrBadLength}, v[2])
+			assert.Equal(Violation{[]int{9, 10}, []string{"Sequence", "SequenceNumber"}, 26, ErrTooMany}, v[3])
+			assert.Equal(Violation{[]int{9, 60, 2}, []string{"Sequence", "CoreInfo", "CoreId"}, 34, ErrBadLength}, v[4])
+			assert.Equal(Violation{[]int{8}, []string{""}, 39, ErrNotAllowed}, v[5])
+			assert.Equal(Violation{[]int{9}, []string{"Sequence"}, 45, ErrTlvUnmarshalNotEnoughData}, v[6])
+			assert.Equal(Violation{[]int{9, 10}, []string{"Sequence", "SequenceNumber"}, 45, ErrRequired}, v[7])
+			assert.Equal("Sequence(9)/CoreInfo(60)/CoreId(2) at offset 34: bad value length", v[4].Error())
+			assert.ErrorIs(err, ErrTooMany)
+		}
+	}
+
+	assert.NoError(dict.ValidateT8L16(T8L16{9, 0, 5, 10, 0, 2, 0, 1}))
+	err = dict.ValidateT8L16(T8L16{9, 0, 0})
+	assert.Equal(Violations{{[]int{9, 10}, []string{"Sequence", "SequenceNumber"}, 3, ErrRequired}}, err)
+}
+
+func TestValidateElements(t *testing.T) {
+	assert := assert.New(t)
+
+	dict, err := ParseDictionary([]byte(testValidateDictionary))
+	assert.NoError(err)
+
+	msg, err := Decode(`Sequence(9):
+    SequenceNumber(10): uint16(1)
+    Operation(11): [9]
+`)
+	assert.NoError(err)
+	err = dict.Validate(msg)
+	assert.Equal(Violations{{[]int{9, 11}, []string{"Sequence", "Operation"}, 8, ErrValueOutOfRange}}, err)
+}
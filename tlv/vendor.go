@@ -0,0 +1,113 @@
+package tlv
+
+// This file has registry of vendor-specific TLVs.
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/cloudcopper/core/encoding/binary"
+	"github.com/cloudcopper/core/encoding/tlv"
+)
+
+// Vendor describes vendor-specific TLVs of single vendor.
+// The vendor packages register it with RegisterVendor.
+type Vendor struct {
+	ID         uint32       // the vendor ID, i.e. 4491 of CableLabs
+	Dictionary Dictionary   // the definitions of vendor-specific TLVs
+	Type       reflect.Type // optional Go type of vendor-specific TLVs for encoding/tlv.Unmarshal
+}
+
+// VendorSpecific is the Go value of vendor-specific TLV (see encoding/tlv.VendorSpecific).
+type VendorSpecific = tlv.VendorSpecific
+
+// RegisterVendor registers definitions and Go type of vendor-specific TLVs of vendor v.ID.
+//
+// The content of TLV with definition of VendorID is described by its Sub
+// and the dictionary of vendor, which ID is the value of the first sub-element
+// of type VendorID. The content of unknown vendor is kept as is,
+// so it is marshaled back unchanged.
+// The Go type of vendor, if any, is registered by encoding/tlv.RegisterVendor.
+//
+// Registering v with nil Dictionary and Type removes the vendor.
+func RegisterVendor(v Vendor) {
+	if v.Dictionary == nil && v.Type == nil {
+		tlv.RegisterVendor(v.ID, nil)
+		vendors.Delete(v.ID)
+		return
+	}
+	// The Go type registered by vendor package directly is kept
+	if v.Type != nil {
+		tlv.RegisterVendor(v.ID, v.Type)
+	}
+	vendors.Store(v.ID, v.Dictionary)
+}
+
+var vendors sync.Map // map[uint32]Dictionary
+
+// The vendorSub returns the dictionary of content of vendor-specific TLV def
+// of vendor id, which is Sub and the dictionary of vendor.
+// It returns false for unknown vendor.
+// The dictionary is merged on each call, as it is small,
+// so it always reflects the registered vendors.
+func (def *Definition) vendorSub(id uint32) (Dictionary, bool) {
+	v, ok := vendors.Load(id)
+	if !ok {
+		return nil, false
+	}
+
+	sub := make(Dictionary, len(def.Sub)+len(v.(Dictionary)))
+	mergeDictionary(sub, def.Sub)
+	mergeDictionary(sub, v.(Dictionary))
+	return sub, true
+}
+
+// The mergeDictionary adds definitions of src missing in dst
+func mergeDictionary(dst, src Dictionary) {
+	for t, def := range src {
+		if _, ok := dst[t]; !ok {
+			dst[t] = def
+		}
+	}
+}
+
+// The vendorOf returns vendor ID of value of vendor ID element
+func vendorOf(v T8L16) (uint32, bool) {
+	u, err := tlv.DecodeUint(v, 4)
+	return uint32(u), err == nil && len(v) != 0
+}
+
+// The subOf returns the dictionary of sub-elements of TLV def with value,
+// which depends on vendor of vendor-specific TLV.
+// It returns false for unknown vendor.
+func (def *Definition) subOf(value T8L16) (Dictionary, bool) {
+	if def.VendorID == 0 {
+		return def.Sub, true
+	}
+	if len(value) < 3 || int(value[0]) != def.VendorID {
+		return def.Sub, false
+	}
+	l := int(binary.NetworkByteOrder.Uint16(value[1:]))
+	if len(value) < 3+l {
+		return def.Sub, false
+	}
+	if id, ok := vendorOf(value[3 : 3+l]); ok {
+		if sub, ok := def.vendorSub(id); ok {
+			return sub, true
+		}
+	}
+	return def.Sub, false
+}
+
+// The subOfElements is the subOf for sub-elements els of TLV def
+func (def *Definition) subOfElements(els Elements) Dictionary {
+	if def.VendorID == 0 || len(els) == 0 || els[0].T != def.VendorID {
+		return def.Sub
+	}
+	if id, ok := vendorOf(els[0].V); ok {
+		if sub, ok := def.vendorSub(id); ok {
+			return sub
+		}
+	}
+	return def.Sub
+}
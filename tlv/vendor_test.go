@@ -0,0 +1,148 @@
+package tlv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cloudcopper/core/encoding/tlv"
+	"github.com/stretchr/testify/assert"
+)
+
+const testVendorDictionary = `
+- name: VendorSpecificExtension
+  type: 21
+  vendorId: 1
+  repeated: true
+  sub:
+    - name: VendorId
+      type: 1
+      size: 2
+      required: true
+`
+
+const testVendorCableLabsDictionary = `
+- name: Mode
+  type: 2
+  size: 1
+  enum: {1: Active, 2: Backup}
+- name: Label
+  type: 3
+  maxLen: 4
+`
+
+type testVendorCableLabs struct {
+	Mode  uint8  `tlv:"2"`
+	Label string `tlv:"3"`
+}
+
+func registerTestVendor(t *testing.T) Dictionary {
+	dict, err := ParseDictionary([]byte(testVendorDictionary))
+	assert.NoError(t, err)
+	vendor, err := ParseDictionary([]byte(testVendorCableLabsDictionary))
+	assert.NoError(t, err)
+
+	RegisterVendor(Vendor{ID: 4491, Dictionary: vendor, Type: reflect.TypeOf(testVendorCableLabs{})})
+	t.Cleanup(func() { RegisterVendor(Vendor{ID: 4491}) })
+	return dict
+}
+
+func TestVendorDecode(t *testing.T) {
+	assert := assert.New(t)
+	dict := registerTestVendor(t)
+
+	yaml := `
+- VendorSpecificExtension:
+    - VendorId: uint16(4491)
+    - Mode: Backup
+    - Label: "abc"
+- VendorSpecificExtension:
+    - VendorId: uint16(7)
+    - 2: [9]
+`
+	out, err := DecodeWithOptions(yaml, DecodeOptions{Dictionary: dict, ResolveNames: true})
+	assert.NoError(err)
+	if assert.Len(out, 2) && assert.Len(out[0].Sub, 3) {
+		assert.Equal("Mode", out[0].Sub[1].Name)
+		assert.Equal(T8L16{2}, out[0].Sub[1].V)
+		assert.Equal("Label", out[0].Sub[2].Name)
+		assert.Nil(out[1].Sub[1].Def)
+	}
+	bin, err := Marshal(out)
+	assert.NoError(err)
+	assert.Equal(T8L16{
+		21, 0, 15, 1, 0, 2, 0x11, 0x8B, 2, 0, 1, 2, 3, 0, 3, 'a', 'b', 'c',
+		21, 0, 9, 1, 0, 2, 0, 7, 2, 0, 1, 9,
+	}, T8L16(bin))
+
+	// The names of unknown vendor are not resolved
+	_, err = DecodeWithOptions("- VendorSpecificExtension:\n    - VendorId: uint16(7)\n    - Mode: Backup\n",
+		DecodeOptions{Dictionary: dict, ResolveNames: true, Strict: true})
+	assert.ErrorIs(err, ErrUnsupportedKey)
+
+	// The generic structure is annotated by dictionary of vendor
+	var generic Elements
+	assert.NoError(UnmarshalT8L16(T8L16(bin), &generic))
+	dict.Annotate(generic)
+	assert.Equal("Mode", generic[0].Sub[1].Name)
+	assert.Empty(generic[1].Sub[1].Name)
+
+	// The same data is decoded to Go type of vendor
+	var v struct {
+		Extensions []VendorSpecific `tlv:"21" vendor:"1"`
+	}
+	_, err = tlv.Unmarshal(T8L16(bin), &v)
+	assert.NoError(err)
+	if assert.Len(v.Extensions, 2) {
+		assert.Equal(&testVendorCableLabs{Mode: 2, Label: "abc"}, v.Extensions[0].Value)
+		assert.Len(v.Extensions[1].Unknown, 1)
+	}
+}
+
+func TestVendorValidate(t *testing.T) {
+	assert := assert.New(t)
+	dict := registerTestVendor(t)
+
+	data := T8L16{
+		21, 0, 18, 1, 0, 2, 0x11, 0x8B, 2, 0, 2, 1, 2, 3, 0, 5, 'a', 'b', 'c', 'd', 'e',
+		21, 0, 9, 1, 0, 2, 0, 7, 2, 0, 1, 9,
+		21, 0, 4, 2, 0, 1, 1,
+	}
+	err := dict.ValidateT8L16(data)
+	if assert.IsType(Violations{}, err) {
+		v := err.(Violations)
+		if assert.Len(v, 4) {
+			assert.Equal(Violation{[]int{21, 2}, []string{"VendorSpecificExtension", "Mode"}, 8, ErrBadLength}, v[0])
+			assert.Equal(Violation{[]int{21, 3}, []string{"VendorSpecificExtension", "Label"}, 13, ErrBadLength}, v[1])
+			// The content of unknown vendor is not validated, but the missing vendor ID is
+			assert.Equal(Violation{[]int{21, 2}, []string{"VendorSpecificExtension", ""}, 36, ErrNotAllowed}, v[2])
+			assert.Equal(Violation{[]int{21, 1}, []string{"VendorSpecificExtension", "VendorId"}, 36, ErrRequired}, v[3])
+		}
+	}
+
+	assert.Contains(Dump(data[:21], dict), "# Label(3)")
+}
+
+func TestRegisterVendorDictionaryOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	vendor, err := ParseDictionary([]byte(testVendorCableLabsDictionary))
+	assert.NoError(err)
+
+	// The Go type registered by vendor package directly is kept
+	tlv.RegisterVendor(7, reflect.TypeOf(testVendorCableLabs{}))
+	RegisterVendor(Vendor{ID: 7, Dictionary: vendor})
+	t.Cleanup(func() { RegisterVendor(Vendor{ID: 7}) })
+
+	var v struct {
+		Vendor tlv.VendorSpecific `tlv:"21" vendor:"1"`
+	}
+	_, err = tlv.Unmarshal(T8L16{21, 0, 9, 1, 0, 2, 0, 7, 2, 0, 1, 1}, &v)
+	assert.NoError(err)
+	assert.Equal(&testVendorCableLabs{Mode: 1}, v.Vendor.Value)
+
+	// The removal of vendor removes its Go type too
+	RegisterVendor(Vendor{ID: 7})
+	_, err = tlv.Unmarshal(T8L16{21, 0, 9, 1, 0, 2, 0, 7, 2, 0, 1, 1}, &v)
+	assert.NoError(err)
+	assert.Nil(v.Vendor.Value)
+}